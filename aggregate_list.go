@@ -0,0 +1,282 @@
+package serialized
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AggregateInfo describes an aggregate, without its events.
+type AggregateInfo struct {
+	ID          string `json:"aggregateId"`
+	Version     int64  `json:"aggregateVersion"`
+	LastUpdated int64  `json:"lastUpdated"`
+}
+
+// LastUpdatedTime returns the time the last events were stored.
+func (a *AggregateInfo) LastUpdatedTime() time.Time {
+	return time.Unix(0, a.LastUpdated*int64(time.Millisecond))
+}
+
+// AggregatePage holds a page of aggregates.
+type AggregatePage struct {
+	Aggregates []*AggregateInfo `json:"aggregates"`
+	HasMore    bool             `json:"hasMore"`
+	TotalCount int              `json:"totalCount"`
+}
+
+// ListAggregates returns a page of the aggregates of a type. Use WithSkip
+// and WithLimit to page through them, and WithSort to sort them by
+// aggregateId, aggregateVersion or lastUpdated.
+//
+// If the server doesn't support listing aggregates, the aggregates are
+// found by reading the whole feed of the aggregate type, which can be slow
+// for large feeds. To page through such aggregates, use an AggregateIndex,
+// which only reads the entries added since it was last refreshed.
+func (c *Client) ListAggregates(ctx context.Context, aggType string, opts ...ListOption) (*AggregatePage, error) {
+	o := newListOptions(opts...)
+
+	page, err := c.aggregatesPage(ctx, aggType, o)
+	if err != errAggregatesNotFound {
+		return page, err
+	}
+
+	// The server responds the same way to an unknown aggregate type as to a
+	// missing endpoint, so the endpoint is only considered missing if the
+	// feed of the type has entries.
+	ok, err := c.feedHasEntries(ctx, aggType)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &AggregatePage{Aggregates: []*AggregateInfo{}}, nil
+	}
+
+	return NewAggregateIndex(c, aggType).List(ctx, opts...)
+}
+
+// errAggregatesNotFound is returned when the server responds that there
+// are no aggregates to list, either because the aggregate type is unknown
+// or because it has no list endpoint.
+var errAggregatesNotFound = errors.New("aggregates not found")
+
+func (c *Client) aggregatesPage(ctx context.Context, aggType string, o ListOptions) (*AggregatePage, error) {
+	u := &url.URL{
+		Path:     "/aggregates/" + aggType,
+		RawQuery: o.values().Encode(),
+	}
+
+	req, err := c.newRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(AggregatePage)
+	resp, err := c.do(ctx, req, page)
+
+	// Servers without the endpoint may respond with a body that isn't JSON,
+	// so the status code is checked before the error.
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return nil, errAggregatesNotFound
+		case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return NewAggregateIndex(c, aggType).list(ctx, o)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return page, nil
+}
+
+// feedHasEntries reports whether the feed of an aggregate type has entries.
+func (c *Client) feedHasEntries(ctx context.Context, aggType string) (bool, error) {
+	req, err := c.newRequest("HEAD", "/feeds/"+aggType, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	seq, err := strconv.ParseInt(resp.Header.Get("Serialized-Sequencenumber-Current"), 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return seq > 0, nil
+}
+
+// AggregateIndex lists the aggregates of a type by reading its feed, for
+// servers that can't list aggregates. The feed is read from the start once,
+// and after that only the entries added since the last refresh are read.
+// Since deletions don't show in the feed, aggregates that have been deleted
+// are still listed.
+type AggregateIndex struct {
+	client  *Client
+	aggType string
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	aggs      []*AggregateInfo
+	byID      map[string]*AggregateInfo
+	seq       int64
+	refreshed time.Time
+}
+
+// NewAggregateIndex returns a new AggregateIndex for an aggregate type.
+func NewAggregateIndex(c *Client, aggType string, opts ...func(*AggregateIndex)) *AggregateIndex {
+	idx := &AggregateIndex{
+		client:  c,
+		aggType: aggType,
+		byID:    make(map[string]*AggregateInfo),
+	}
+
+	for _, f := range opts {
+		f(idx)
+	}
+
+	return idx
+}
+
+// WithAggregateIndexTTL makes List use the aggregates read by the last
+// refresh, unless it's older than d. By default, List refreshes the index
+// each time.
+func WithAggregateIndexTTL(d time.Duration) func(*AggregateIndex) {
+	return func(idx *AggregateIndex) {
+		idx.ttl = d
+	}
+}
+
+// Refresh reads the entries added to the feed since the last refresh.
+func (idx *AggregateIndex) Refresh(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.refresh(ctx)
+}
+
+func (idx *AggregateIndex) refresh(ctx context.Context) error {
+	for {
+		f, err := idx.client.feed(ctx, idx.aggType, idx.seq)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range f.Entries {
+			agg, ok := idx.byID[e.AggregateID]
+			if !ok {
+				agg = &AggregateInfo{ID: e.AggregateID}
+				idx.byID[e.AggregateID] = agg
+				idx.aggs = append(idx.aggs, agg)
+			}
+			agg.Version += int64(len(e.Events))
+			agg.LastUpdated = e.Timestamp
+
+			idx.seq = e.SequenceNumber
+		}
+
+		if !f.HasMore || len(f.Entries) == 0 {
+			idx.refreshed = time.Now()
+			return nil
+		}
+	}
+}
+
+// List returns a page of the aggregates in the index, refreshing it first
+// unless the last refresh is within the TTL of the index. Aggregates are
+// ordered by the time they were created, unless sorted otherwise.
+func (idx *AggregateIndex) List(ctx context.Context, opts ...ListOption) (*AggregatePage, error) {
+	return idx.list(ctx, newListOptions(opts...))
+}
+
+func (idx *AggregateIndex) list(ctx context.Context, o ListOptions) (*AggregatePage, error) {
+	idx.mu.Lock()
+
+	var err error
+	if idx.refreshed.IsZero() || time.Since(idx.refreshed) >= idx.ttl {
+		err = idx.refresh(ctx)
+	}
+
+	// The index keeps changing, so the page holds copies.
+	aggs := make([]*AggregateInfo, len(idx.aggs))
+	for i, agg := range idx.aggs {
+		cp := *agg
+		aggs[i] = &cp
+	}
+	idx.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sortAggregates(aggs, o.SortBy, o.Descending)
+
+	page := &AggregatePage{TotalCount: len(aggs)}
+
+	if o.Skip < len(aggs) {
+		aggs = aggs[o.Skip:]
+	} else {
+		aggs = nil
+	}
+	if o.Limit > 0 && len(aggs) > o.Limit {
+		aggs = aggs[:o.Limit]
+		page.HasMore = true
+	}
+
+	page.Aggregates = aggs
+	if page.Aggregates == nil {
+		page.Aggregates = []*AggregateInfo{}
+	}
+
+	return page, nil
+}
+
+func sortAggregates(aggs []*AggregateInfo, field string, descending bool) {
+	var less func(a, b *AggregateInfo) bool
+
+	switch field {
+	case "aggregateId":
+		less = func(a, b *AggregateInfo) bool { return a.ID < b.ID }
+	case "aggregateVersion":
+		less = func(a, b *AggregateInfo) bool { return a.Version < b.Version }
+	case "lastUpdated":
+		less = func(a, b *AggregateInfo) bool { return a.LastUpdated < b.LastUpdated }
+	default:
+		if descending {
+			for i, j := 0, len(aggs)-1; i < j; i, j = i+1, j-1 {
+				aggs[i], aggs[j] = aggs[j], aggs[i]
+			}
+		}
+		return
+	}
+
+	sort.SliceStable(aggs, func(i, j int) bool {
+		if descending {
+			return less(aggs[j], aggs[i])
+		}
+		return less(aggs[i], aggs[j])
+	})
+}
@@ -0,0 +1,305 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListAggregates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aggregates/payment" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		want := url.Values{
+			"skip":  []string{"1"},
+			"limit": []string{"1"},
+		}
+		if got := r.URL.Query(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected query = %v; want = %v", got, want)
+		}
+
+		b, err := loadJSON("testdata/aggregate_list_response.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	page, err := c.ListAggregates(context.Background(), "payment", WithSkip(1), WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &AggregatePage{
+		Aggregates: []*AggregateInfo{
+			{ID: "2c3cf88c-ee88-427e-818a-ab0267511c84", Version: 2, LastUpdated: 1505306479000},
+		},
+		HasMore:    true,
+		TotalCount: 2,
+	}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("got = %+v; want = %+v", page, want)
+	}
+}
+
+// newAggregateFeedServer returns a server without the endpoint for listing
+// aggregates, along with a function that returns the since parameter of
+// each feed read, and the number of list requests.
+func newAggregateFeedServer(t *testing.T) (*httptest.Server, func() ([]string, int)) {
+	feed := []*Feed{
+		{
+			Entries: []*FeedEntry{
+				{SequenceNumber: 1, AggregateID: "a", Timestamp: 100, Events: []*Event{{Type: "Created"}}},
+				{SequenceNumber: 2, AggregateID: "b", Timestamp: 200, Events: []*Event{{Type: "Created"}, {Type: "Updated"}}},
+			},
+			HasMore: true,
+		},
+		{
+			Entries: []*FeedEntry{
+				{SequenceNumber: 3, AggregateID: "a", Timestamp: 300, Events: []*Event{{Type: "Updated"}}},
+				{SequenceNumber: 4, AggregateID: "c", Timestamp: 400, Events: []*Event{{Type: "Created"}}},
+			},
+		},
+	}
+
+	var (
+		mu    sync.Mutex
+		lists int
+		reads []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/aggregates/payment":
+			lists++
+			http.NotFound(w, r)
+		case r.URL.Path == "/feeds/payment" && r.Method == "HEAD":
+			w.Header().Set("Serialized-Sequencenumber-Current", "4")
+		case r.URL.Path == "/feeds/payment":
+			since := r.URL.Query().Get("since")
+			reads = append(reads, since)
+
+			f := &Feed{}
+			switch since {
+			case "":
+				f = feed[0]
+			case "2":
+				f = feed[1]
+			}
+			if err := json.NewEncoder(w).Encode(f); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+
+	return ts, func() ([]string, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return reads, lists
+	}
+}
+
+func TestListAggregatesFromFeed(t *testing.T) {
+	ts, requests := newAggregateFeedServer(t)
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	for i := 0; i < 2; i++ {
+		page, err := c.ListAggregates(context.Background(), "payment", WithLimit(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &AggregatePage{
+			Aggregates: []*AggregateInfo{
+				{ID: "a", Version: 2, LastUpdated: 300},
+				{ID: "b", Version: 2, LastUpdated: 200},
+			},
+			HasMore:    true,
+			TotalCount: 3,
+		}
+		if !reflect.DeepEqual(page, want) {
+			t.Fatalf("got = %+v; want = %+v", page, want)
+		}
+	}
+
+	// Nothing is kept between calls.
+	reads, lists := requests()
+	if lists != 2 {
+		t.Fatalf("unexpected number of list requests = %d; want = %d", lists, 2)
+	}
+	if want := []string{"", "2", "", "2"}; !reflect.DeepEqual(reads, want) {
+		t.Fatalf("unexpected feed reads = %q; want = %q", reads, want)
+	}
+}
+
+func TestAggregateIndex(t *testing.T) {
+	ts, requests := newAggregateFeedServer(t)
+
+	idx := NewAggregateIndex(NewClient(WithBaseURL(ts.URL)), "payment")
+
+	var tests = []struct {
+		opts []ListOption
+		want *AggregatePage
+	}{
+		{
+			opts: nil,
+			want: &AggregatePage{
+				Aggregates: []*AggregateInfo{
+					{ID: "a", Version: 2, LastUpdated: 300},
+					{ID: "b", Version: 2, LastUpdated: 200},
+					{ID: "c", Version: 1, LastUpdated: 400},
+				},
+				TotalCount: 3,
+			},
+		},
+		{
+			opts: []ListOption{WithSort("lastUpdated", true), WithLimit(2)},
+			want: &AggregatePage{
+				Aggregates: []*AggregateInfo{
+					{ID: "c", Version: 1, LastUpdated: 400},
+					{ID: "a", Version: 2, LastUpdated: 300},
+				},
+				HasMore:    true,
+				TotalCount: 3,
+			},
+		},
+		{
+			opts: []ListOption{WithSkip(5)},
+			want: &AggregatePage{
+				Aggregates: []*AggregateInfo{},
+				TotalCount: 3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		page, err := idx.List(context.Background(), tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(page, tt.want) {
+			t.Fatalf("got = %+v; want = %+v", page, tt.want)
+		}
+	}
+
+	// The feed is read from the start once, and from the head after that.
+	reads, lists := requests()
+	if lists != 0 {
+		t.Fatalf("unexpected number of list requests = %d; want = %d", lists, 0)
+	}
+	if want := []string{"", "2", "4", "4"}; !reflect.DeepEqual(reads, want) {
+		t.Fatalf("unexpected feed reads = %q; want = %q", reads, want)
+	}
+}
+
+func TestAggregateIndexTTL(t *testing.T) {
+	ts, requests := newAggregateFeedServer(t)
+
+	idx := NewAggregateIndex(NewClient(WithBaseURL(ts.URL)), "payment", WithAggregateIndexTTL(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		page, err := idx.List(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalCount != 3 {
+			t.Fatalf("unexpected total count = %d; want = %d", page.TotalCount, 3)
+		}
+	}
+
+	if reads, _ := requests(); !reflect.DeepEqual(reads, []string{"", "2"}) {
+		t.Fatalf("unexpected feed reads = %q; want = %q", reads, []string{"", "2"})
+	}
+
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if reads, _ := requests(); !reflect.DeepEqual(reads, []string{"", "2", "4"}) {
+		t.Fatalf("unexpected feed reads = %q; want = %q", reads, []string{"", "2", "4"})
+	}
+}
+
+func TestListAggregatesUnknownType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/aggregates/payment":
+			http.NotFound(w, r)
+		case r.URL.Path == "/feeds/payment" && r.Method == "HEAD":
+			http.NotFound(w, r)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	page, err := c.ListAggregates(context.Background(), "payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &AggregatePage{Aggregates: []*AggregateInfo{}}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("got = %+v; want = %+v", page, want)
+	}
+}
+
+func TestListAggregatesNotImplemented(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aggregates/payment":
+			w.WriteHeader(http.StatusNotImplemented)
+		case "/feeds/payment":
+			f := &Feed{Entries: []*FeedEntry{
+				{SequenceNumber: 1, AggregateID: "a", Timestamp: 100, Events: []*Event{{Type: "Created"}}},
+			}}
+			if err := json.NewEncoder(w).Encode(f); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	page, err := c.ListAggregates(context.Background(), "payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &AggregatePage{
+		Aggregates: []*AggregateInfo{{ID: "a", Version: 1, LastUpdated: 100}},
+		TotalCount: 1,
+	}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("got = %+v; want = %+v", page, want)
+	}
+}
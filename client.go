@@ -23,13 +23,15 @@ type Client struct {
 	httpClient *http.Client
 }
 
+// DefaultBaseURL is the base URL used unless WithBaseURL is given.
+const DefaultBaseURL = "https://api.serialized.io"
+
 // NewClient returns a new Serialized.io Client.
 func NewClient(opts ...func(*Client)) *Client {
+	baseURL, _ := url.Parse(DefaultBaseURL)
+
 	c := &Client{
-		baseURL: &url.URL{
-			Scheme: "https",
-			Host:   "api.serialized.io",
-		},
+		baseURL:      baseURL,
 		userAgent:    "serialized-go/0.1.0",
 		pollInterval: 2 * time.Second,
 		httpClient:   &http.Client{},
@@ -72,6 +74,13 @@ func WithPollInterval(d time.Duration) func(*Client) {
 	}
 }
 
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(hc *http.Client) func(*Client) {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
 func (c *Client) newRequest(method, path string, body interface{}) (*http.Request, error) {
 	u, err := c.baseURL.Parse(path)
 	if err != nil {
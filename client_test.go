@@ -0,0 +1,51 @@
+package serialized
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"feeds":[]}`))
+	}))
+	defer ts.Close()
+
+	var requests int
+
+	hc := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithHTTPClient(hc),
+	)
+
+	if _, err := c.Feeds(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("requests = %d; want = %d", requests, 1)
+	}
+}
+
+func TestNewClientDefaultBaseURL(t *testing.T) {
+	c := NewClient()
+
+	if got := c.baseURL.String(); got != DefaultBaseURL {
+		t.Fatalf("got = %s; want = %s", got, DefaultBaseURL)
+	}
+}
@@ -4,77 +4,197 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	serialized "github.com/marcusolsson/serialized-go"
 )
 
-func aggregatesGetHandler(c *serialized.Client, aggType, aggID string, limit int) error {
+func aggregatesGetHandler(c *serialized.Client, out *printer, aggType, aggID string, limit int) error {
 	agg, err := c.LoadAggregate(context.Background(), aggType, aggID)
 	if err != nil {
 		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 5, 4, 1, ' ', 0)
-	fmt.Fprintln(w, "Type:", "\t", agg.Type)
-	fmt.Fprintln(w, "ID:", "\t", agg.ID)
-	fmt.Fprintln(w, "Version:", "\t", agg.Version)
-	fmt.Fprintln(w)
-	fmt.Fprintf(w, "Showing the %d most recent events:\n", limit)
-	fmt.Fprintln(w)
+	if len(agg.Events) > limit {
+		agg.Events = agg.Events[len(agg.Events)-limit:]
+	}
+
+	return out.Print(agg, func(tw *tabwriter.Writer) error {
+		w := tabwriter.NewWriter(tw, 5, 4, 1, ' ', 0)
+		fmt.Fprintln(w, "Type:", "\t", agg.Type)
+		fmt.Fprintln(w, "ID:", "\t", agg.ID)
+		fmt.Fprintln(w, "Version:", "\t", agg.Version)
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Showing the %d most recent events:\n", limit)
+		fmt.Fprintln(w)
+
+		w.Flush()
+
+		fmt.Fprintln(w, "EVENT ID", "\t", "TYPE", "\t", "DATA")
 
-	w.Flush()
+		for _, e := range agg.Events {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, e.Data); err != nil {
+				return err
+			}
+			fmt.Fprintln(w, e.ID, "\t", e.Type, "\t", buf.String())
+		}
 
-	fmt.Fprintln(w, "EVENT ID", "\t", "TYPE", "\t", "DATA")
+		return w.Flush()
+	})
+}
 
-	events := agg.Events
-	if len(events) > limit {
-		events = events[len(events)-limit:]
+func aggregatesListHandler(c *serialized.Client, out *printer, aggType string, skip, limit int, sort string) error {
+	opts := []serialized.ListOption{
+		serialized.WithSkip(skip),
+		serialized.WithLimit(limit),
 	}
-	for _, e := range events {
-		var buf bytes.Buffer
-		if err := json.Compact(&buf, e.Data); err != nil {
-			return err
+	if sort != "" {
+		opts = append(opts, serialized.WithSort(strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")))
+	}
+
+	page, err := c.ListAggregates(context.Background(), aggType, opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(page.Aggregates) == 0 && out.table() {
+		fmt.Println("No aggregates found.")
+		return nil
+	}
+
+	err = out.Print(page.Aggregates, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"ID", "VERSION", "LAST UPDATED"}, "\t"))
+		for _, a := range page.Aggregates {
+			fmt.Fprintln(w, strings.Join([]string{a.ID, fmt.Sprint(a.Version), a.LastUpdatedTime().Format(time.RFC1123Z)}, "\t"))
 		}
-		fmt.Fprintln(w, e.ID, "\t", e.Type, "\t", buf.String())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if page.HasMore && out.table() {
+		fmt.Printf("\nMore aggregates available. Use --skip=%d to show the next page.\n", skip+len(page.Aggregates))
 	}
-	w.Flush()
 
 	return nil
 }
 
-func aggregatesDeleteHandler(c *serialized.Client, aggType string) error {
+// deleteOptions holds the flags of the aggregates delete command.
+type deleteOptions struct {
+	aggID  string
+	yes    bool
+	dryRun bool
+}
+
+func aggregatesDeleteHandler(c *serialized.Client, aggType string, opts deleteOptions) error {
 	ctx := context.Background()
 
-	token, err := c.RequestDeleteAggregateByType(ctx, aggType)
+	aggs, events, err := deleteSummary(ctx, c, aggType, opts.aggID)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("WARNING: This will permanently delete all aggregates of type %q, including all associated events.\n", aggType)
-	fmt.Printf("Are you sure you want to delete all aggregates of type %q? (yes/no): ", aggType)
+	if aggs == 0 {
+		fmt.Printf("No aggregates of type %q found. No changes were made.\n", aggType)
+		return nil
+	}
 
-	var answer string
-	_, err = fmt.Scan(&answer)
-	if err != nil {
-		return err
+	if opts.aggID != "" {
+		fmt.Printf("WARNING: This will permanently delete aggregate %q of type %q, including %d events.\n", opts.aggID, aggType, events)
+	} else {
+		fmt.Printf("WARNING: This will permanently delete %d aggregates of type %q, including %d events.\n", aggs, aggType, events)
+	}
+
+	if opts.dryRun {
+		fmt.Println("Dry run. No changes were made.")
+		return nil
+	}
+
+	if !opts.yes {
+		if !isTerminal(os.Stdin) {
+			return errors.New("confirmation required, use --yes to delete without a prompt")
+		}
+
+		fmt.Print("Are you sure you want to continue? (yes/no): ")
+
+		var answer string
+		if _, err := fmt.Scan(&answer); err != nil {
+			return err
+		}
+
+		if !yesOrNo(answer) {
+			fmt.Println("Canceled. No changes were made.")
+			return nil
+		}
 	}
 
-	if yesOrNo(answer) {
-		if err := c.DeleteAggregateByType(ctx, aggType, token); err != nil {
+	// The token is only requested once the deletion has been confirmed.
+	if opts.aggID != "" {
+		token, err := c.RequestDeleteAggregate(ctx, aggType, opts.aggID)
+		if err != nil {
 			return err
 		}
-		fmt.Println("Successfully deleted aggregates.")
-	} else {
-		fmt.Println("Canceled. No changes were made.")
+		if err := c.DeleteAggregate(ctx, aggType, opts.aggID, token); err != nil {
+			return err
+		}
+		fmt.Println("Successfully deleted aggregate.")
+		return nil
+	}
+
+	token, err := c.RequestDeleteAggregateByType(ctx, aggType)
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteAggregateByType(ctx, aggType, token); err != nil {
+		return err
 	}
+	fmt.Println("Successfully deleted aggregates.")
 
 	return nil
 }
 
+// deleteSummary returns the number of aggregates and events that would be
+// deleted. Without an aggregate ID, the counts are read from the feed of
+// the aggregate type.
+func deleteSummary(ctx context.Context, c *serialized.Client, aggType, aggID string) (int, int, error) {
+	if aggID != "" {
+		exists, err := c.AggregateExists(ctx, aggType, aggID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !exists {
+			return 0, 0, serialized.ErrAggregateNotFound
+		}
+
+		agg, err := c.LoadAggregate(ctx, aggType, aggID)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return 1, len(agg.Events), nil
+	}
+
+	feeds, err := c.Feeds(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, f := range feeds {
+		if f.AggregateType == aggType {
+			return f.AggregateCount, f.EventCount, nil
+		}
+	}
+
+	return 0, 0, nil
+}
+
 func yesOrNo(str string) bool {
 	return strings.ToLower(str) == "yes"
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// clipboardCommands are the commands tried, in order, to copy text to the
+// clipboard.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// copyToClipboard copies text to the clipboard. If none of the clipboard
+// commands are available, such as over SSH, the terminal is asked to set
+// the clipboard by writing an OSC 52 escape sequence to term.
+func copyToClipboard(text string, term io.Writer) error {
+	for _, args := range clipboardCommands {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	_, err := fmt.Fprintf(term, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+// completionTTL is how long resource names are cached for completion.
+const completionTTL = 30 * time.Second
+
+// completionTimeout limits how long completion waits for the API.
+const completionTimeout = 2 * time.Second
+
+// completer completes the names of resources in the account of the selected
+// profile. Since completion runs every time tab is pressed, names are cached
+// for a short while.
+type completer struct {
+	profileName *string
+	baseURL     *string
+}
+
+// completionCache maps a profile, base URL and kind of resource to names.
+type completionCache map[string]*completionEntry
+
+type completionEntry struct {
+	Names     []string  `json:"names"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+func (c *completer) feeds() []string {
+	return c.names("feeds", func(ctx context.Context, client *serialized.Client) ([]string, error) {
+		feeds, err := client.Feeds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, f := range feeds {
+			names = append(names, f.AggregateType)
+		}
+		return names, nil
+	})
+}
+
+func (c *completer) projections() []string {
+	return c.names("projections", func(ctx context.Context, client *serialized.Client) ([]string, error) {
+		defs, err := client.ListProjectionDefinitions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, d := range defs {
+			names = append(names, d.Name)
+		}
+		return names, nil
+	})
+}
+
+func (c *completer) reactions() []string {
+	return c.names("reactions", func(ctx context.Context, client *serialized.Client) ([]string, error) {
+		defs, err := client.ListReactionDefinitions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, d := range defs {
+			names = append(names, d.Name)
+		}
+		return names, nil
+	})
+}
+
+// profiles completes the names of the profiles in the configuration file.
+func (c *completer) profiles() []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// names returns the cached names of a kind of resource, or fetches them if
+// they're missing or stale. Errors result in no completions, rather than in
+// output that would end up on the command line.
+func (c *completer) names(kind string, fetch func(context.Context, *serialized.Client) ([]string, error)) []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s|%s|%s", cfg.profileName(*c.profileName), *c.baseURL, kind)
+
+	cache := loadCompletionCache()
+	if e, ok := cache[key]; ok && time.Since(e.FetchedAt) < completionTTL {
+		return e.Names
+	}
+
+	opts, err := clientOptions(cfg, *c.profileName, *c.baseURL)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	names, err := fetch(ctx, serialized.NewClient(opts...))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(names)
+
+	cache[key] = &completionEntry{Names: names, FetchedAt: time.Now()}
+	cache.save()
+
+	return names
+}
+
+func completionCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cereal", "completion.json"), nil
+}
+
+// loadCompletionCache reads the cache, dropping entries that have expired.
+// A missing or invalid cache results in an empty cache.
+func loadCompletionCache() completionCache {
+	cache := make(completionCache)
+
+	path, err := completionCachePath()
+	if err != nil {
+		return cache
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return make(completionCache)
+	}
+
+	for key, e := range cache {
+		if e == nil || time.Since(e.FetchedAt) >= completionTTL {
+			delete(cache, key)
+		}
+	}
+
+	return cache
+}
+
+// save writes the cache. Failing to write it only makes completion slower,
+// so errors are ignored.
+func (cache completionCache) save() {
+	path, err := completionCachePath()
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+
+	ioutil.WriteFile(path, b, 0600)
+}
+
+func completionHandler(shell string) error {
+	scripts := map[string]string{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+
+	script, ok := scripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+
+	_, err := io.WriteString(os.Stdout, script)
+	return err
+}
+
+// The completion scripts ask cereal for completions of the words before the
+// one being completed, using the --completion-bash flag, and let the shell
+// match them against the current word. Flags are passed along, so that
+// flags and their values can be completed as well.
+
+const bashCompletion = `# bash completion for cereal
+#
+# Add the following to ~/.bashrc:
+#
+#   source <(cereal completion bash)
+
+_cereal_completions() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local words=("${COMP_WORDS[@]:1:COMP_CWORD-1}")
+    if [[ "$cur" == -* ]]; then
+        words+=("$cur")
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$("${COMP_WORDS[0]}" --completion-bash "${words[@]}" 2>/dev/null)" -- "$cur"))
+}
+
+complete -o default -F _cereal_completions cereal
+`
+
+const zshCompletion = `#compdef cereal
+#
+# zsh completion for cereal. Add the following to ~/.zshrc, after compinit:
+#
+#   source <(cereal completion zsh)
+
+_cereal() {
+    local cur="${words[CURRENT]}"
+    local -a args opts
+    args=("${(@)words[2,CURRENT-1]}")
+    if [[ "$cur" == -* ]]; then
+        args+=("$cur")
+    fi
+
+    opts=("${(@f)$("${words[1]}" --completion-bash "${args[@]}" 2>/dev/null)}")
+    opts=("${(@)opts:#}")
+
+    if (( ${#opts} )); then
+        compadd -- "${opts[@]}"
+    else
+        _files
+    fi
+}
+
+compdef _cereal cereal
+`
+
+const fishCompletion = `# fish completion for cereal
+#
+# Save it to ~/.config/fish/completions/cereal.fish:
+#
+#   cereal completion fish > ~/.config/fish/completions/cereal.fish
+
+function __cereal_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    set -l cur (commandline -ct)
+    if string match -q -- '-*' $cur
+        set args $args $cur
+    end
+
+    set -l opts (cereal --completion-bash $args 2>/dev/null)
+    if test (count $opts) -gt 0
+        printf '%s\n' $opts
+    else
+        __fish_complete_path $cur
+    end
+end
+
+complete -c cereal -f -a '(__cereal_complete)'
+`
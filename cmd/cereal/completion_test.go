@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompleterReactions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CEREAL_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("SERIALIZED_ACCESS_KEY", "key")
+	t.Setenv("SERIALIZED_SECRET_ACCESS_KEY", "secret")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"definitions":[{"reactionName":"payment-reminder"},{"reactionName":"order-shipped"}]}`))
+	}))
+	defer ts.Close()
+
+	// The API isn't reachable at all.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	profileName := ""
+	for _, tt := range []struct {
+		baseURL string
+		want    []string
+	}{
+		{baseURL: ts.URL, want: []string{"order-shipped", "payment-reminder"}},
+		{baseURL: down.URL},
+	} {
+		baseURL := tt.baseURL
+		c := &completer{profileName: &profileName, baseURL: &baseURL}
+
+		if got := c.reactions(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got = %v; want = %v", tt.baseURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+	"golang.org/x/term"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const defaultProfile = "default"
+
+// config is the cereal configuration file.
+type config struct {
+	CurrentProfile string              `yaml:"currentProfile,omitempty" json:"currentProfile,omitempty"`
+	Profiles       map[string]*profile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+// profile holds the settings for one Serialized.io project.
+type profile struct {
+	AccessKey       string `yaml:"accessKey,omitempty" json:"accessKey,omitempty"`
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty" json:"secretAccessKey,omitempty"`
+	BaseURL         string `yaml:"baseUrl,omitempty" json:"baseUrl,omitempty"`
+	PollInterval    string `yaml:"pollInterval,omitempty" json:"pollInterval,omitempty"`
+}
+
+// configKeys are the profile settings that can be changed with config set.
+var configKeys = []string{"access-key", "secret-access-key", "base-url", "poll-interval"}
+
+// secretConfigKeys are read from the terminal rather than from the command
+// line, to keep them out of the shell history.
+var secretConfigKeys = []string{"access-key", "secret-access-key"}
+
+// configPath returns the path to the configuration file. It can be changed
+// using the CEREAL_CONFIG environment variable.
+func configPath() (string, error) {
+	if p := os.Getenv("CEREAL_CONFIG"); p != "" {
+		return p, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "cereal", "config.yaml"), nil
+}
+
+// loadConfig reads the configuration file. A missing file results in an
+// empty configuration.
+func loadConfig() (*config, error) {
+	cfg := &config{Profiles: make(map[string]*profile)}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+
+	return cfg, nil
+}
+
+// save writes the configuration file. Since it holds credentials, the file
+// is only readable by the current user.
+func (cfg *config) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// profileName returns the name of the profile to use, given the value of
+// the --profile flag.
+func (cfg *config) profileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if cfg.CurrentProfile != "" {
+		return cfg.CurrentProfile
+	}
+	return defaultProfile
+}
+
+// profile returns the named profile, or an empty profile if it doesn't
+// exist.
+func (cfg *config) profile(name string) *profile {
+	if p, ok := cfg.Profiles[cfg.profileName(name)]; ok {
+		return p
+	}
+	return &profile{}
+}
+
+// clientOptions returns the client options for the given profile. Access
+// keys from the environment take precedence over the default profile, but
+// not over a profile named with --profile or selected with use-profile. The
+// base URL flag takes precedence over both.
+func clientOptions(cfg *config, profileName, baseURL string) ([]func(*serialized.Client), error) {
+	p, err := resolveProfile(cfg, profileName, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return p.clientOptions()
+}
+
+// resolveProfile returns the settings of the given profile, with the access
+// keys from the environment and the base URL flag applied. The access keys
+// from the environment are only used for the implicit default profile, so
+// that they aren't sent to the base URL of another account.
+func resolveProfile(cfg *config, profileName, baseURL string) (*profile, error) {
+	p, err := cfg.lookupProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	env := *p
+	if cfg.implicitProfile(profileName) {
+		if v := os.Getenv("SERIALIZED_ACCESS_KEY"); v != "" {
+			env.AccessKey = v
+		}
+		if v := os.Getenv("SERIALIZED_SECRET_ACCESS_KEY"); v != "" {
+			env.SecretAccessKey = v
+		}
+	}
+	if baseURL != "" {
+		env.BaseURL = baseURL
+	}
+
+	return &env, nil
+}
+
+// implicitProfile reports whether the default profile is used because no
+// profile was named with --profile or selected with use-profile.
+func (cfg *config) implicitProfile(name string) bool {
+	return name == "" && cfg.CurrentProfile == ""
+}
+
+// lookupProfile returns the named profile. Unless a name is given, it's
+// fine for the profile not to exist.
+func (cfg *config) lookupProfile(name string) (*profile, error) {
+	if name != "" {
+		if _, ok := cfg.Profiles[name]; !ok {
+			return nil, fmt.Errorf("profile %q not found", name)
+		}
+	}
+	return cfg.profile(name), nil
+}
+
+// clientOptions returns the client options for the profile alone.
+func (p *profile) clientOptions() ([]func(*serialized.Client), error) {
+	opts := []func(*serialized.Client){
+		serialized.WithAccessKey(p.AccessKey),
+		serialized.WithSecretAccessKey(p.SecretAccessKey),
+	}
+
+	if p.BaseURL != "" {
+		if err := validateBaseURL(p.BaseURL); err != nil {
+			return nil, err
+		}
+		opts = append(opts, serialized.WithBaseURL(p.BaseURL))
+	}
+
+	if p.PollInterval != "" {
+		d, err := time.ParseDuration(p.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval: %s", err)
+		}
+		opts = append(opts, serialized.WithPollInterval(d))
+	}
+
+	return opts, nil
+}
+
+func validateBaseURL(rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %s", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid base URL %q: must be an absolute http or https URL", rawurl)
+	}
+	return nil
+}
+
+func configSetHandler(profileName, key, value string) error {
+	if !containsString(configKeys, key) {
+		return fmt.Errorf("unknown key %q, must be one of: %s", key, strings.Join(configKeys, ", "))
+	}
+
+	if value != "" && containsString(secretConfigKeys, key) {
+		return fmt.Errorf("%s can't be given as an argument, enter it when prompted instead", key)
+	}
+
+	if value == "" {
+		v, err := readValue(key, containsString(secretConfigKeys, key))
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	switch key {
+	case "base-url":
+		if err := validateBaseURL(value); err != nil {
+			return err
+		}
+	case "poll-interval":
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid poll interval: %s", err)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	name := cfg.profileName(profileName)
+
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		p = &profile{}
+		cfg.Profiles[name] = p
+	}
+
+	switch key {
+	case "access-key":
+		p.AccessKey = value
+	case "secret-access-key":
+		p.SecretAccessKey = value
+	case "base-url":
+		p.BaseURL = value
+	case "poll-interval":
+		p.PollInterval = value
+	}
+
+	if err := cfg.save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s set for profile %q\n", key, name)
+
+	return nil
+}
+
+// readValue reads a value from stdin. Secret values aren't echoed when
+// stdin is a terminal.
+func readValue(key string, secret bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "%s: ", key)
+
+		if secret {
+			b, err := term.ReadPassword(fd)
+			fmt.Fprintln(os.Stderr)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(b)), nil
+		}
+	}
+
+	s, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && s == "" {
+		return "", fmt.Errorf("unable to read %s: %s", key, err)
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", errors.New("no value given")
+	}
+
+	return s, nil
+}
+
+func configGetHandler(out *printer, profileName string, showSecrets bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	name := cfg.profileName(profileName)
+
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	shown := *p
+	if !showSecrets {
+		shown.AccessKey = redact(shown.AccessKey)
+		shown.SecretAccessKey = redact(shown.SecretAccessKey)
+	}
+
+	return out.Print(&shown, func(w *tabwriter.Writer) error {
+		fmt.Fprint(w, kv("Profile", name, 0))
+		fmt.Fprint(w, kv("Access key", shown.AccessKey, 0))
+		fmt.Fprint(w, kv("Secret access key", shown.SecretAccessKey, 0))
+		fmt.Fprint(w, kv("Base URL", shown.BaseURL, 0))
+		fmt.Fprint(w, kv("Poll interval", shown.PollInterval, 0))
+		return nil
+	})
+}
+
+func configListHandler(out *printer) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	current := cfg.profileName("")
+
+	var names []string
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return out.Print(names, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"CURRENT", "NAME", "BASE URL"}, "\t"))
+		for _, name := range names {
+			var mark string
+			if name == current {
+				mark = "*"
+			}
+			fmt.Fprintln(w, strings.Join([]string{mark, name, cfg.Profiles[name].BaseURL}, "\t"))
+		}
+		return nil
+	})
+}
+
+func configUseProfileHandler(name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	cfg.CurrentProfile = name
+
+	if err := cfg.save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("switched to profile %q\n", name)
+
+	return nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveProfile(t *testing.T) {
+	profiles := map[string]*profile{
+		"default": {AccessKey: "default-key", SecretAccessKey: "default-secret", BaseURL: "https://default.example.com"},
+		"staging": {AccessKey: "staging-key", SecretAccessKey: "staging-secret", BaseURL: "https://staging.example.com"},
+	}
+
+	var tests = []struct {
+		name        string
+		current     string
+		profileName string
+		baseURL     string
+		env         bool
+		want        *profile
+		wantErr     bool
+	}{
+		{
+			name: "default",
+			want: profiles["default"],
+		},
+		{
+			name: "env over default",
+			env:  true,
+			want: &profile{AccessKey: "env-key", SecretAccessKey: "env-secret", BaseURL: "https://default.example.com"},
+		},
+		{
+			name:        "flag",
+			profileName: "staging",
+			want:        profiles["staging"],
+		},
+		{
+			name:        "flag over env",
+			profileName: "staging",
+			env:         true,
+			want:        profiles["staging"],
+		},
+		{
+			name:    "current profile",
+			current: "staging",
+			want:    profiles["staging"],
+		},
+		{
+			name:    "current profile over env",
+			current: "staging",
+			env:     true,
+			want:    profiles["staging"],
+		},
+		{
+			name:        "flag over current profile",
+			current:     "staging",
+			profileName: "default",
+			want:        profiles["default"],
+		},
+		{
+			name:    "base URL flag",
+			current: "staging",
+			baseURL: "http://localhost:8080",
+			want:    &profile{AccessKey: "staging-key", SecretAccessKey: "staging-secret", BaseURL: "http://localhost:8080"},
+		},
+		{
+			name:    "base URL flag with env",
+			env:     true,
+			baseURL: "http://localhost:8080",
+			want:    &profile{AccessKey: "env-key", SecretAccessKey: "env-secret", BaseURL: "http://localhost:8080"},
+		},
+		{
+			name:        "missing profile",
+			profileName: "prod",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env {
+				t.Setenv("SERIALIZED_ACCESS_KEY", "env-key")
+				t.Setenv("SERIALIZED_SECRET_ACCESS_KEY", "env-secret")
+			} else {
+				t.Setenv("SERIALIZED_ACCESS_KEY", "")
+				t.Setenv("SERIALIZED_SECRET_ACCESS_KEY", "")
+			}
+
+			cfg := &config{CurrentProfile: tt.current, Profiles: profiles}
+
+			got, err := resolveProfile(cfg, tt.profileName, tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; wantErr = %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %+v; want = %+v", got, tt.want)
+			}
+		})
+	}
+}
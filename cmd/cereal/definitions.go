@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	serialized "github.com/marcusolsson/serialized-go"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func projectionsDefinitionsCreateHandler(c *serialized.Client, filename string, replace bool, fromExisting string) error {
+	ctx := context.Background()
+
+	if fromExisting != "" {
+		def, err := c.ProjectionDefinition(ctx, fromExisting)
+		if err != nil {
+			return err
+		}
+		if err := writeDefinitionFile(filename, def); err != nil {
+			return err
+		}
+
+		fmt.Printf("projection definition %q written to %s\n", fromExisting, filename)
+		fmt.Printf("When you're done editing, run: cereal projections definitions create -f %s --replace\n", filename)
+
+		return nil
+	}
+
+	var def serialized.ProjectionDefinition
+	if err := decodeFile(filename, &def); err != nil {
+		return err
+	}
+
+	if err := def.Validate(); err != nil {
+		return fmt.Errorf("invalid projection definition: %s", err)
+	}
+
+	defs, err := c.ListProjectionDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	for _, d := range defs {
+		if d.Name == def.Name {
+			exists = true
+		}
+	}
+
+	if exists {
+		if !replace {
+			return fmt.Errorf("projection definition %q already exists, use --replace to replace it", def.Name)
+		}
+		if err := c.UpdateProjectionDefinition(ctx, &def); err != nil {
+			return err
+		}
+		fmt.Printf("projection definition %q replaced\n", def.Name)
+		return nil
+	}
+
+	if err := c.CreateProjectionDefinition(ctx, &def); err != nil {
+		return err
+	}
+
+	fmt.Printf("projection definition %q created\n", def.Name)
+
+	return nil
+}
+
+func reactionsDefinitionsCreateHandler(c *serialized.Client, filename string, replace bool, fromExisting string) error {
+	ctx := context.Background()
+
+	if fromExisting != "" {
+		def, err := c.ReactionDefinition(ctx, fromExisting)
+		if err != nil {
+			return err
+		}
+
+		// The signing secret is left out of the file, so that it isn't
+		// committed by accident. It's kept when the definition is replaced.
+		def, hasSecret := withoutSigningSecret(def)
+
+		if err := writeDefinitionFile(filename, def); err != nil {
+			return err
+		}
+
+		fmt.Printf("reaction definition %q written to %s\n", fromExisting, filename)
+		if hasSecret {
+			fmt.Println("The signing secret was left out, and is kept unless you set one.")
+		}
+		fmt.Printf("When you're done editing, run: cereal reactions definitions create -f %s --replace\n", filename)
+
+		return nil
+	}
+
+	var def serialized.ReactionDefinition
+	if err := decodeFile(filename, &def); err != nil {
+		return err
+	}
+
+	// Actions ignore unknown fields when decoded, so that definitions from
+	// newer versions of the API can be read. Files are checked strictly.
+	if def.Action != nil {
+		if unknown := def.Action.UnknownFields(); len(unknown) > 0 {
+			return fmt.Errorf("invalid file %s: unknown field %q in action", filename, unknown[0])
+		}
+	}
+
+	if err := def.Validate(); err != nil {
+		return fmt.Errorf("invalid reaction definition: %s", err)
+	}
+
+	defs, err := c.ListReactionDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var existing *serialized.ReactionDefinition
+	for _, d := range defs {
+		if d.Name == def.Name {
+			existing = d
+		}
+	}
+
+	if existing != nil {
+		if !replace {
+			return fmt.Errorf("reaction definition %q already exists, use --replace to replace it", def.Name)
+		}
+		keepSigningSecret(&def, existing)
+		if err := c.UpdateReactionDefinition(ctx, &def); err != nil {
+			return err
+		}
+		fmt.Printf("reaction definition %q replaced\n", def.Name)
+		return nil
+	}
+
+	if err := c.CreateReactionDefinition(ctx, &def); err != nil {
+		return err
+	}
+
+	fmt.Printf("reaction definition %q created\n", def.Name)
+
+	return nil
+}
+
+// withoutSigningSecret returns a copy of def without the signing secret of
+// its HTTP_POST action, and whether it had one.
+func withoutSigningSecret(def *serialized.ReactionDefinition) (*serialized.ReactionDefinition, bool) {
+	if def.Action == nil {
+		return def, false
+	}
+
+	cfg, ok := def.Action.Config.(*serialized.HTTPConfig)
+	if !ok || cfg.SigningSecret == "" {
+		return def, false
+	}
+
+	stripped := *cfg
+	stripped.SigningSecret = ""
+
+	action := *def.Action
+	action.Config = &stripped
+
+	res := *def
+	res.Action = &action
+
+	return &res, true
+}
+
+// keepSigningSecret copies the signing secret of the existing definition to
+// def, unless def sets one of its own.
+func keepSigningSecret(def, existing *serialized.ReactionDefinition) {
+	if def.Action == nil || existing.Action == nil || def.Action.ActionType != serialized.ActionTypeHTTPPost {
+		return
+	}
+
+	old, ok := existing.Action.Config.(*serialized.HTTPConfig)
+	if !ok || old.SigningSecret == "" {
+		return
+	}
+
+	cfg, ok := def.Action.Config.(*serialized.HTTPConfig)
+	if !ok {
+		cfg = new(serialized.HTTPConfig)
+		def.Action.Config = cfg
+	}
+	if cfg.SigningSecret == "" {
+		cfg.SigningSecret = old.SigningSecret
+	}
+}
+
+// decodeFile decodes a YAML or JSON file into v. Since YAML is a
+// superset of JSON, every file is read as YAML and then normalized to JSON,
+// so that v is decoded using its JSON tags.
+func decodeFile(filename string, v interface{}) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(b, &generic); err != nil {
+		return fmt.Errorf("invalid file %s: %s", filename, err)
+	}
+	if b, err = json.Marshal(normalizeYAML(generic)); err != nil {
+		return fmt.Errorf("invalid file %s: %s", filename, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid file %s: %s", filename, err)
+	}
+
+	return nil
+}
+
+// writeDefinitionFile writes v to a new file, as YAML if the file has a
+// .yaml or .yml extension, and as JSON otherwise. Existing files are never
+// overwritten, to avoid losing any edits. Definitions may hold credentials,
+// such as action headers, so the file is only readable by the user.
+func writeDefinitionFile(filename string, v interface{}) error {
+	if _, err := os.Stat(filename); err == nil {
+		return fmt.Errorf("%s already exists", filename)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		b, err = toYAML(v)
+	default:
+		b, err = json.MarshalIndent(v, "", "  ")
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, b, 0600)
+}
+
+// normalizeYAML converts the maps decoded by yaml.v2 to maps with string
+// keys, so that they can be encoded as JSON.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeYAML(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+const (
+	// doctorTimeout limits how long each network check may take.
+	doctorTimeout = 10 * time.Second
+
+	// doctorLatencySamples is the number of requests used to measure
+	// latency.
+	doctorLatencySamples = 3
+
+	// doctorMaxClockSkew is the clock skew above which a warning is shown.
+	// The Date header only has a resolution of seconds.
+	doctorMaxClockSkew = 5 * time.Second
+
+	// doctorCertExpiry is how long before the certificate expires a warning
+	// is shown.
+	doctorCertExpiry = 14 * 24 * time.Hour
+)
+
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// doctorReport is the result of cereal doctor. Secrets are redacted, so
+// that the report can be shared.
+type doctorReport struct {
+	Time            string         `yaml:"time" json:"time"`
+	Platform        string         `yaml:"platform" json:"platform"`
+	ConfigFile      string         `yaml:"configFile" json:"configFile"`
+	Profile         string         `yaml:"profile" json:"profile"`
+	BaseURL         string         `yaml:"baseUrl" json:"baseUrl"`
+	AccessKey       string         `yaml:"accessKey" json:"accessKey"`
+	SecretAccessKey string         `yaml:"secretAccessKey" json:"secretAccessKey"`
+	Proxy           string         `yaml:"proxy" json:"proxy"`
+	Checks          []*doctorCheck `yaml:"checks" json:"checks"`
+	Feeds           []string       `yaml:"feeds" json:"feeds"`
+	Projections     []string       `yaml:"projections" json:"projections"`
+	Reactions       []string       `yaml:"reactions" json:"reactions"`
+}
+
+type doctorCheck struct {
+	Name   string `yaml:"name" json:"name"`
+	Status string `yaml:"status" json:"status"`
+	Detail string `yaml:"detail" json:"detail"`
+}
+
+// doctorResponse describes the last response received by the doctor.
+type doctorResponse struct {
+	status   int
+	date     time.Time
+	received time.Time
+	duration time.Duration
+}
+
+// doctorTransport records the responses of the requests sent by the client,
+// to report status codes, latency and clock skew.
+type doctorTransport struct {
+	last *doctorResponse
+}
+
+func (t *doctorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.last = &doctorResponse{
+		status:   resp.StatusCode,
+		received: time.Now(),
+		duration: time.Since(start),
+	}
+	if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		t.last.date = d
+	}
+
+	return resp, nil
+}
+
+// doctor runs the checks, in order. Since each check depends on the ones
+// before it, the remaining checks are skipped once one fails.
+type doctor struct {
+	report *doctorReport
+
+	profile *profile
+	baseURL *url.URL
+	proxied bool
+
+	transport *doctorTransport
+	client    *serialized.Client
+}
+
+func doctorHandler(out *printer, profileName, baseURL string) error {
+	d := &doctor{
+		report: &doctorReport{
+			Time:     time.Now().Format(time.RFC3339),
+			Platform: fmt.Sprintf("%s/%s, %s", runtime.GOOS, runtime.GOARCH, runtime.Version()),
+		},
+		transport: new(doctorTransport),
+	}
+
+	steps := []struct {
+		name string
+		fn   func() (string, string)
+	}{
+		{"config", func() (string, string) { return d.checkConfig(profileName, baseURL) }},
+		{"proxy", d.checkProxy},
+		{"dns", d.checkDNS},
+		{"tls", d.checkTLS},
+		{"credentials", d.checkCredentials},
+		{"clock", d.checkClock},
+		{"latency", d.checkLatency},
+		{"projections", d.checkProjections},
+		{"reactions", d.checkReactions},
+	}
+
+	var failed []string
+	for _, s := range steps {
+		check := &doctorCheck{Name: s.name, Status: checkSkip, Detail: "skipped after failed checks"}
+		if len(failed) == 0 {
+			check.Status, check.Detail = s.fn()
+		}
+		if check.Status == checkFail {
+			failed = append(failed, s.name)
+		}
+		d.report.Checks = append(d.report.Checks, check)
+	}
+
+	if err := out.Print(d.report, d.report.write); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed checks: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (d *doctor) checkConfig(profileName, baseURL string) (string, string) {
+	if path, err := configPath(); err == nil {
+		d.report.ConfigFile = path
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return checkFail, err.Error()
+	}
+
+	d.report.Profile = cfg.profileName(profileName)
+
+	p, err := resolveProfile(cfg, profileName, baseURL)
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	d.profile = p
+
+	fromEnv := cfg.implicitProfile(profileName)
+	d.report.AccessKey = describeSecret(p.AccessKey, "SERIALIZED_ACCESS_KEY", fromEnv)
+	d.report.SecretAccessKey = describeSecret(p.SecretAccessKey, "SERIALIZED_SECRET_ACCESS_KEY", fromEnv)
+
+	d.report.BaseURL = p.BaseURL
+	if d.report.BaseURL == "" {
+		d.report.BaseURL = serialized.DefaultBaseURL
+	}
+	if err := validateBaseURL(d.report.BaseURL); err != nil {
+		return checkFail, err.Error()
+	}
+	d.baseURL, _ = url.Parse(d.report.BaseURL)
+
+	opts, err := p.clientOptions()
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	opts = append(opts, serialized.WithHTTPClient(&http.Client{
+		Transport: d.transport,
+		Timeout:   doctorTimeout,
+	}))
+	d.client = serialized.NewClient(opts...)
+
+	if !fromEnv && (os.Getenv("SERIALIZED_ACCESS_KEY") != "" || os.Getenv("SERIALIZED_SECRET_ACCESS_KEY") != "") {
+		return checkWarn, fmt.Sprintf("using profile %q, access keys in the environment are ignored", d.report.Profile)
+	}
+
+	return checkOK, fmt.Sprintf("using profile %q", d.report.Profile)
+}
+
+// describeSecret redacts a secret, and tells whether it was set in the
+// environment.
+func describeSecret(s, envVar string, fromEnv bool) string {
+	if s == "" {
+		return "(not set)"
+	}
+	if fromEnv && os.Getenv(envVar) != "" {
+		return redact(s) + " (from " + envVar + ")"
+	}
+	return redact(s)
+}
+
+func (d *doctor) checkProxy() (string, string) {
+	req := &http.Request{URL: d.baseURL}
+
+	proxy, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		return checkFail, fmt.Sprintf("invalid proxy setting: %s", err)
+	}
+	if proxy == nil {
+		d.report.Proxy = "(none)"
+		return checkOK, "no proxy"
+	}
+
+	d.proxied = true
+	d.report.Proxy = proxy.Redacted()
+
+	return checkOK, "requests go through " + d.report.Proxy
+}
+
+func (d *doctor) checkDNS() (string, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, d.baseURL.Hostname())
+	if err != nil {
+		if d.proxied {
+			return checkWarn, fmt.Sprintf("%s, the proxy may resolve it", err)
+		}
+		return checkFail, err.Error()
+	}
+
+	return checkOK, fmt.Sprintf("%s resolves to %s in %s", d.baseURL.Hostname(), strings.Join(addrs, ", "), roundDuration(time.Since(start)))
+}
+
+func (d *doctor) checkTLS() (string, string) {
+	if d.baseURL.Scheme != "https" {
+		return checkWarn, "base URL doesn't use TLS, access keys are sent in plain text"
+	}
+	if d.proxied {
+		return checkSkip, "connections go through the proxy"
+	}
+
+	host := d.baseURL.Host
+	if d.baseURL.Port() == "" {
+		host = net.JoinHostPort(d.baseURL.Hostname(), "443")
+	}
+
+	start := time.Now()
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: doctorTimeout}, "tcp", host, &tls.Config{
+		ServerName: d.baseURL.Hostname(),
+	})
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	defer conn.Close()
+
+	elapsed := time.Since(start)
+
+	state := conn.ConnectionState()
+	cert := state.PeerCertificates[0]
+
+	detail := fmt.Sprintf("%s, certificate issued by %s, expires %s, handshake in %s",
+		tls.VersionName(state.Version),
+		cert.Issuer.CommonName,
+		cert.NotAfter.Format("2006-01-02"),
+		roundDuration(elapsed))
+
+	if time.Until(cert.NotAfter) < doctorCertExpiry {
+		return checkWarn, detail
+	}
+
+	return checkOK, detail
+}
+
+// checkCredentials lists the feeds, since it's a cheap request that
+// requires valid access keys.
+func (d *doctor) checkCredentials() (string, string) {
+	var missing []string
+	if d.profile.AccessKey == "" {
+		missing = append(missing, "access-key")
+	}
+	if d.profile.SecretAccessKey == "" {
+		missing = append(missing, "secret-access-key")
+	}
+	if len(missing) > 0 {
+		return checkFail, fmt.Sprintf("missing %s, run cereal config set %s", strings.Join(missing, " and "), missing[0])
+	}
+
+	feeds, err := d.client.Feeds(context.Background())
+	if err != nil {
+		if last := d.transport.last; last != nil {
+			switch last.status {
+			case http.StatusUnauthorized, http.StatusForbidden:
+				return checkFail, fmt.Sprintf("access keys were rejected (%d %s), check the access keys of profile %q",
+					last.status, http.StatusText(last.status), d.report.Profile)
+			}
+			if last.status >= 300 {
+				return checkFail, fmt.Sprintf("server responded with %d %s, check the base URL", last.status, http.StatusText(last.status))
+			}
+		}
+		return checkFail, err.Error()
+	}
+
+	d.report.Feeds = []string{}
+	for _, f := range feeds {
+		d.report.Feeds = append(d.report.Feeds, f.AggregateType)
+	}
+	sort.Strings(d.report.Feeds)
+
+	return checkOK, fmt.Sprintf("access keys accepted, feeds visible: %d", len(feeds))
+}
+
+// checkClock compares the local clock to the Date header of the last
+// response. The time the response took is taken into account, by assuming
+// the server set the header halfway through it.
+func (d *doctor) checkClock() (string, string) {
+	last := d.transport.last
+	if last == nil || last.date.IsZero() {
+		return checkSkip, "the server didn't send its time"
+	}
+
+	skew := last.received.Add(-last.duration / 2).Sub(last.date).Round(time.Second)
+
+	ahead := "ahead of"
+	if skew < 0 {
+		ahead = "behind"
+		skew = -skew
+	}
+	if skew == 0 {
+		return checkOK, "local clock matches the server, within a second"
+	}
+	detail := fmt.Sprintf("local clock is %s %s the server", skew, ahead)
+
+	if skew > doctorMaxClockSkew {
+		return checkWarn, detail + ", scheduled reactions and timestamps may look off"
+	}
+
+	return checkOK, detail
+}
+
+func (d *doctor) checkLatency() (string, string) {
+	var samples []time.Duration
+
+	for i := 0; i < doctorLatencySamples; i++ {
+		if _, err := d.client.Feeds(context.Background()); err != nil {
+			return checkFail, err.Error()
+		}
+		samples = append(samples, d.transport.last.duration)
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	return checkOK, fmt.Sprintf("min %s, median %s, max %s over %d requests",
+		roundDuration(samples[0]),
+		roundDuration(samples[len(samples)/2]),
+		roundDuration(samples[len(samples)-1]),
+		len(samples))
+}
+
+func (d *doctor) checkProjections() (string, string) {
+	defs, err := d.client.ListProjectionDefinitions(context.Background())
+	if err != nil {
+		return checkFail, err.Error()
+	}
+
+	d.report.Projections = []string{}
+	for _, def := range defs {
+		d.report.Projections = append(d.report.Projections, def.Name)
+	}
+	sort.Strings(d.report.Projections)
+
+	return checkOK, fmt.Sprintf("definitions visible: %d", len(defs))
+}
+
+func (d *doctor) checkReactions() (string, string) {
+	defs, err := d.client.ListReactionDefinitions(context.Background())
+	if err != nil {
+		return checkFail, err.Error()
+	}
+
+	d.report.Reactions = []string{}
+	for _, def := range defs {
+		d.report.Reactions = append(d.report.Reactions, def.Name)
+	}
+	sort.Strings(d.report.Reactions)
+
+	return checkOK, fmt.Sprintf("definitions visible: %d", len(defs))
+}
+
+// write writes the report as text, for pasting into an issue or a chat.
+func (r *doctorReport) write(w *tabwriter.Writer) error {
+	fmt.Fprint(w, kv("Time", r.Time, 0))
+	fmt.Fprint(w, kv("Platform", r.Platform, 0))
+	fmt.Fprint(w, kv("Config file", r.ConfigFile, 0))
+	fmt.Fprint(w, kv("Profile", r.Profile, 0))
+	fmt.Fprint(w, kv("Base URL", r.BaseURL, 0))
+	fmt.Fprint(w, kv("Access key", r.AccessKey, 0))
+	fmt.Fprint(w, kv("Secret access key", r.SecretAccessKey, 0))
+	fmt.Fprint(w, kv("Proxy", r.Proxy, 0))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, strings.Join([]string{"CHECK", "STATUS", "DETAIL"}, "\t"))
+	for _, c := range r.Checks {
+		fmt.Fprintln(w, strings.Join([]string{c.Name, strings.ToUpper(c.Status), c.Detail}, "\t"))
+	}
+
+	for _, l := range []struct {
+		title string
+		names []string
+	}{
+		{"Feeds", r.Feeds},
+		{"Projections", r.Projections},
+		{"Reactions", r.Reactions},
+	} {
+		if l.names == nil {
+			continue
+		}
+		fmt.Fprintln(w)
+		fmt.Fprint(w, kv(l.title, fmt.Sprint(len(l.names)), 0))
+		for _, name := range l.names {
+			fmt.Fprintln(w, "  "+name)
+		}
+	}
+
+	return nil
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond)
+	case d < time.Second:
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Millisecond)
+}
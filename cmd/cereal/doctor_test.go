@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func TestDoctorCheckReactionsTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	d := &doctor{
+		report: &doctorReport{},
+		client: serialized.NewClient(
+			serialized.WithBaseURL(ts.URL),
+			serialized.WithHTTPClient(&http.Client{Timeout: 10 * time.Millisecond}),
+		),
+	}
+
+	status, detail := d.checkReactions()
+	if status != checkFail {
+		t.Fatalf("unexpected status = %s (%s); want = %s", status, detail, checkFail)
+	}
+	if d.report.Reactions != nil {
+		t.Errorf("unexpected reactions = %v", d.report.Reactions)
+	}
+}
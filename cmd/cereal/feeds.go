@@ -1,21 +1,18 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"text/tabwriter"
 	"time"
 
-	"github.com/alecthomas/kingpin"
 	serialized "github.com/marcusolsson/serialized-go"
 )
 
-func feedsGetHandler(c *serialized.Client, feed string, since int64, showCurrent bool) error {
-	ctx := context.Background()
+func feedsGetHandler(c *serialized.Client, out *printer, feed string, since int64, showCurrent bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	if showCurrent {
 		seq, err := c.FeedSequenceNumber(ctx, feed)
@@ -23,45 +20,103 @@ func feedsGetHandler(c *serialized.Client, feed string, since int64, showCurrent
 			return fmt.Errorf("unable to get sequence number: %s", err)
 		}
 
-		fmt.Println(seq)
+		return out.Print(seq, func(w *tabwriter.Writer) error {
+			_, err := fmt.Fprintln(w, seq)
+			return err
+		})
+	}
 
-		return nil
+	// Entries of the page being read are still passed to the callback after
+	// the context is cancelled, so they're skipped once printing fails.
+	var printErr error
+	follow := func(print func(*serialized.FeedEntry) error) error {
+		err := c.Feed(ctx, feed, since, func(e *serialized.FeedEntry) {
+			if printErr != nil {
+				return
+			}
+			if err := print(e); err != nil {
+				printErr = fmt.Errorf("unable to print feed entry: %s", err)
+				cancel()
+			}
+		})
+		if printErr != nil {
+			return printErr
+		}
+		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	if !out.table() {
+		return follow(func(e *serialized.FeedEntry) error {
+			return out.Print(e, nil)
+		})
+	}
 
+	// Entries are flushed as they arrive, so the columns are given a
+	// minimum width that fits timestamps and UUIDs, to line up across
+	// entries.
+	w := out.newRowWriter(feedColumnWidth)
 	fmt.Fprintln(w, strings.Join([]string{"TIMESTAMP", "AGGREGATE ID", "EVENT TYPE"}, "\t"))
+	if err := w.Flush(); err != nil {
+		return err
+	}
 
-	return c.Feed(ctx, feed, since, func(e *serialized.FeedEntry) {
+	return follow(func(e *serialized.FeedEntry) error {
 		ts := time.Unix(e.Timestamp/1000, 0)
 
 		for _, ev := range e.Events {
-			var buf bytes.Buffer
-			if err := json.Compact(&buf, ev.Data); err != nil {
-				kingpin.Fatalf("unable to format event data: %s", err)
-			}
-
 			fmt.Fprintln(w, strings.Join([]string{ts.Format(time.RFC1123Z), e.AggregateID, ev.Type}, "\t"))
-
-			w.Flush()
 		}
+
+		return w.Flush()
 	})
 }
 
-func feedsListHandler(c *serialized.Client) error {
+// feedColumnWidth is the minimum width of the columns of a feed listing.
+const feedColumnWidth = 40
+
+func feedsListHandler(c *serialized.Client, out *printer) error {
 	feeds, err := c.Feeds(context.Background())
 	if err != nil {
 		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	return out.Print(feeds, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"TYPE", "AGGREGATES", "BATCHES", "EVENTS"}, "\t"))
 
-	fmt.Fprintln(w, strings.Join([]string{"TYPE", "AGGREGATES", "BATCHES", "EVENTS"}, "\t"))
+		for _, f := range feeds {
+			fmt.Fprintln(w, fmt.Sprintf("%s\t%d\t%d\t%d",
+				f.AggregateType, f.AggregateCount, f.BatchCount, f.EventCount))
+		}
+
+		return nil
+	})
+}
+
+func feedsExportHandler(c *serialized.Client, out *printer, feed string, since, until int64, path, compress string, chunkSize int64) error {
+	compression := serialized.Compression(compress)
+
+	if path == "" {
+		path = feed + ".ndjson"
+	}
+	if !strings.HasSuffix(path, compression.Ext()) {
+		path += compression.Ext()
+	}
 
-	for _, f := range feeds {
-		fmt.Fprintln(w, fmt.Sprintf("%s\t%d\t%d\t%d",
-			f.AggregateType, f.AggregateCount, f.BatchCount, f.EventCount))
+	m, err := c.ExportFeed(context.Background(), feed, path,
+		serialized.WithExportRange(since, until),
+		serialized.WithExportCompression(compression),
+		serialized.WithExportChunkSize(chunkSize),
+	)
+	if err != nil {
+		return err
 	}
 
-	return w.Flush()
+	return out.Print(m, func(w *tabwriter.Writer) error {
+		fmt.Fprintf(w, "Exported %d entries (%d-%d) from feed %q.\n\n", m.Entries, m.Since, m.Until, m.Feed)
+		fmt.Fprintln(w, strings.Join([]string{"FILE", "FIRST", "LAST", "ENTRIES", "SIZE", "SHA256"}, "\t"))
+		for _, ch := range m.Chunks {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", ch.File, ch.FirstSequenceNumber, ch.LastSequenceNumber, ch.Entries, ch.Size, ch.SHA256)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestFeedsGetPrintError(t *testing.T) {
+	for _, output := range []string{outputTable, outputNDJSON} {
+		t.Run(output, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				f := &serialized.Feed{Entries: []*serialized.FeedEntry{
+					{SequenceNumber: 1, AggregateID: "a", Events: []*serialized.Event{{ID: "1", Type: "Created"}}},
+					{SequenceNumber: 2, AggregateID: "b", Events: []*serialized.Event{{ID: "2", Type: "Created"}}},
+				}}
+				if err := json.NewEncoder(w).Encode(f); err != nil {
+					t.Error(err)
+				}
+			}))
+			defer ts.Close()
+
+			c := serialized.NewClient(
+				serialized.WithBaseURL(ts.URL),
+				serialized.WithPollInterval(time.Millisecond),
+			)
+
+			out, err := newPrinter(failingWriter{}, output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- feedsGetHandler(c, out, "order", 0, false) }()
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("handler didn't return")
+			}
+		})
+	}
+}
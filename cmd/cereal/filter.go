@@ -0,0 +1,562 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filterExpr is a jq-like expression evaluated against event data, such as
+//
+//	.amount > 100 and .currency == "SEK"
+//	select(.items[0].sku != null)
+//
+// Paths select fields of the data, and can be compared to strings, numbers,
+// booleans and null using ==, !=, <, <=, > and >=. Expressions can be
+// combined using and, or and not, and grouped using parentheses. Like in
+// jq, a value is true unless it's false or null. As a filter, select(f)
+// matches the same events as f.
+//
+// Numbers support +, -, * and /. Strings can be concatenated using +, and
+// adding null to a value results in the value.
+type filterExpr interface {
+	eval(data interface{}) interface{}
+}
+
+// parseFilter parses a filter expression.
+func parseFilter(s string) (filterExpr, error) {
+	toks, err := lexFilter(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{toks: toks}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+
+	return expr, nil
+}
+
+// matchFilter reports whether the JSON data matches the expression.
+func matchFilter(expr filterExpr, data json.RawMessage) bool {
+	var v interface{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return false
+		}
+	}
+	return truthy(expr.eval(v))
+}
+
+type tokenKind int
+
+const (
+	tokPath tokenKind = iota
+	tokString
+	tokNumber
+	tokIdent
+	tokOp
+	tokArith
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func lexFilter(s string) ([]token, error) {
+	var toks []token
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		r, _ := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case c == '.':
+			j := i + 1
+			for j < len(s) {
+				if strings.IndexByte(".[]", s[j]) >= 0 {
+					j++
+				} else if k := scanIdent(s, j); k > j {
+					j = k
+				} else {
+					break
+				}
+			}
+			toks = append(toks, token{tokPath, s[i:j]})
+			i = j
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string %s", s[i:])
+			}
+			str, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", s[i:j+1])
+			}
+			toks = append(toks, token{tokString, str})
+			i = j + 1
+		case strings.IndexByte("+-*/", c) >= 0 && (c != '-' || followsOperand(toks)):
+			toks = append(toks, token{tokArith, string(c)})
+			i++
+		case c == '-' || isDigit(c):
+			j := i + 1
+			for j < len(s) && (isDigit(s[j]) || s[j] == 'e' || s[j] == 'E' || (s[j] == '.' && j+1 < len(s) && isDigit(s[j+1]))) {
+				j++
+			}
+			toks = append(toks, token{tokNumber, s[i:j]})
+			i = j
+		case strings.IndexByte("=!<>", c) >= 0:
+			j := i + 1
+			if j < len(s) && s[j] == '=' {
+				j++
+			}
+			op := s[i:j]
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("invalid operator %q", op)
+			}
+			toks = append(toks, token{tokOp, op})
+			i = j
+		case isIdentRune(r):
+			j := scanIdent(s, i)
+			toks = append(toks, token{tokIdent, s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return toks, nil
+}
+
+// followsOperand reports whether the last token ends an operand, in which
+// case a - is a subtraction rather than the sign of a number.
+func followsOperand(toks []token) bool {
+	if len(toks) == 0 {
+		return false
+	}
+	switch t := toks[len(toks)-1]; t.kind {
+	case tokPath, tokString, tokNumber, tokRParen:
+		return true
+	case tokIdent:
+		return t.text == "true" || t.text == "false" || t.text == "null"
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanIdent returns the index of the first byte after the identifier that
+// starts at i.
+func scanIdent(s string, i int) int {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isIdentRune(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+type filterParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *filterParser) peek() *token {
+	if p.pos < len(p.toks) {
+		return &p.toks[p.pos]
+	}
+	return nil
+}
+
+func (p *filterParser) acceptIdent(name string) bool {
+	if t := p.peek(); t != nil && t.kind == tokIdent && t.text == name {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptIdent("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptIdent("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterExpr, error) {
+	if p.acceptIdent("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *filterParser) parseCompare() (filterExpr, error) {
+	left, err := p.parseArith("+-")
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t == nil || t.kind != tokOp {
+		return left, nil
+	}
+	p.pos++
+
+	right, err := p.parseArith("+-")
+	if err != nil {
+		return nil, err
+	}
+
+	return compareExpr{op: t.text, left: left, right: right}, nil
+}
+
+// parseArith parses the arithmetic operators in ops, which binds looser than
+// * and /.
+func (p *filterParser) parseArith(ops string) (filterExpr, error) {
+	next := func() (filterExpr, error) {
+		if ops == "+-" {
+			return p.parseArith("*/")
+		}
+		return p.parseOperand()
+	}
+
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t == nil || t.kind != tokArith || !strings.Contains(ops, t.text) {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = arithExpr{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *filterParser) parseOperand() (filterExpr, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokPath:
+		return parsePath(t.text)
+	case tokString:
+		return literal{t.text}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return literal{f}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		case "select":
+			if t := p.peek(); t == nil || t.kind != tokLParen {
+				return nil, fmt.Errorf("missing ( after select")
+			}
+			p.pos++
+			expr, err := p.parseGroup()
+			if err != nil {
+				return nil, err
+			}
+			return selectExpr{expr}, nil
+		}
+	case tokLParen:
+		return p.parseGroup()
+	}
+
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// parseGroup parses an expression followed by a closing parenthesis.
+func (p *filterParser) parseGroup() (filterExpr, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t == nil || t.kind != tokRParen {
+		return nil, fmt.Errorf("missing )")
+	}
+	p.pos++
+	return expr, nil
+}
+
+// pathExpr selects a value using object keys and array indices.
+type pathExpr []interface{}
+
+func parsePath(s string) (pathExpr, error) {
+	var path pathExpr
+
+	s = strings.TrimPrefix(s, ".")
+	for s != "" {
+		switch {
+		case s[0] == '.':
+			s = s[1:]
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in path")
+			}
+			n, err := strconv.Atoi(s[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q", s[1:end])
+			}
+			path = append(path, n)
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			path = append(path, s[:end])
+			s = s[end:]
+		}
+	}
+
+	return path, nil
+}
+
+func (p pathExpr) eval(v interface{}) interface{} {
+	for _, seg := range p {
+		switch seg := seg.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[seg]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok {
+				return nil
+			}
+			if seg < 0 {
+				seg += len(a)
+			}
+			if seg < 0 || seg >= len(a) {
+				return nil
+			}
+			v = a[seg]
+		}
+	}
+	return v
+}
+
+type arithExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e arithExpr) eval(v interface{}) interface{} {
+	l, r := e.left.eval(v), e.right.eval(v)
+
+	if e.op == "+" {
+		if l == nil {
+			return r
+		}
+		if r == nil {
+			return l
+		}
+		if ls, ok := l.(string); ok {
+			if rs, ok := r.(string); ok {
+				return ls + rs
+			}
+		}
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil
+	}
+
+	switch e.op {
+	case "+":
+		return lf + rf
+	case "-":
+		return lf - rf
+	case "*":
+		return lf * rf
+	case "/":
+		if rf == 0 {
+			return nil
+		}
+		return lf / rf
+	}
+
+	return nil
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l literal) eval(interface{}) interface{} { return l.v }
+
+// selectExpr is true if its condition is. Unlike in jq, it doesn't evaluate
+// to the data, so that events without data can still be selected.
+type selectExpr struct{ cond filterExpr }
+
+func (e selectExpr) eval(v interface{}) interface{} {
+	return truthy(e.cond.eval(v))
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) eval(v interface{}) interface{} {
+	return truthy(e.left.eval(v)) && truthy(e.right.eval(v))
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) eval(v interface{}) interface{} {
+	return truthy(e.left.eval(v)) || truthy(e.right.eval(v))
+}
+
+type notExpr struct{ expr filterExpr }
+
+func (e notExpr) eval(v interface{}) interface{} {
+	return !truthy(e.expr.eval(v))
+}
+
+type compareExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e compareExpr) eval(v interface{}) interface{} {
+	l, r := e.left.eval(v), e.right.eval(v)
+
+	switch e.op {
+	case "==":
+		return equalJSON(l, r)
+	case "!=":
+		return !equalJSON(l, r)
+	}
+
+	if lf, ok := l.(float64); ok {
+		if rf, ok := r.(float64); ok {
+			return compareOrdered(e.op, lf < rf, lf == rf)
+		}
+	}
+	if ls, ok := l.(string); ok {
+		if rs, ok := r.(string); ok {
+			return compareOrdered(e.op, ls < rs, ls == rs)
+		}
+	}
+
+	return false
+}
+
+func compareOrdered(op string, less, equal bool) bool {
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	case ">=":
+		return !less
+	}
+	return false
+}
+
+func equalJSON(a, b interface{}) bool {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(ab) == string(bb)
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	return true
+}
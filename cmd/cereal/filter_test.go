@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMatchFilter(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		data string
+		want bool
+	}{
+		// Paths
+		{expr: `.amount > 100`, data: `{"amount":150}`, want: true},
+		{expr: `.card.last4 == "1234"`, data: `{"card":{"last4":"1234"}}`, want: true},
+		{expr: `.items[0].sku == "a"`, data: `{"items":[{"sku":"a"},{"sku":"b"}]}`, want: true},
+		{expr: `.items[2] == null`, data: `{"items":[1,2]}`, want: true},
+		{expr: `.items.sku == null`, data: `{"items":[1,2]}`, want: true},
+		{expr: `.a.b == null`, data: `{"a":"b"}`, want: true},
+		{expr: `.belopp_kr > 100`, data: `{"belopp_kr":150}`, want: true},
+		{expr: `.åtgärd == "betala"`, data: `{"åtgärd":"betala"}`, want: true},
+		{expr: `.kund.ort == "Malmö"`, data: `{"kund":{"ort":"Malmö"}}`, want: true},
+		{expr: `.värde[0] == 1`, data: `{"värde":[1]}`, want: true},
+
+		// select(...)
+		{expr: `select(.amount > 100)`, data: `{"amount":150}`, want: true},
+		{expr: ` select(.amount > 100) `, data: `{"amount":50}`, want: false},
+		{expr: `select(.a) and select(.b)`, data: `{"a":1,"b":2}`, want: true},
+		{expr: `select(.a) and select(.b)`, data: `{"a":1}`, want: false},
+		{expr: `not select(.a == 1) or (.b)`, data: `{"a":2}`, want: true},
+		{expr: `select(.a == null)`, data: ``, want: true},
+
+		// Truthiness
+		{expr: `.a`, data: `{"a":0}`, want: true},
+		{expr: `.a`, data: `{"a":""}`, want: true},
+		{expr: `.a`, data: `{"a":[]}`, want: true},
+		{expr: `.a`, data: `{"a":false}`, want: false},
+		{expr: `.a`, data: `{"a":null}`, want: false},
+		{expr: `.a`, data: `{}`, want: false},
+		{expr: `not .a`, data: `{}`, want: true},
+		{expr: `.a`, data: ``, want: false},
+		{expr: `.a`, data: `{invalid`, want: false},
+
+		// Logical operators
+		{expr: `.a or .b and .c`, data: `{"a":true}`, want: true},
+		{expr: `(.a or .b) and .c`, data: `{"a":true}`, want: false},
+		{expr: `not not .a`, data: `{"a":1}`, want: true},
+
+		// Comparisons across types
+		{expr: `.a == 1`, data: `{"a":1.0}`, want: true},
+		{expr: `.a == "1"`, data: `{"a":1}`, want: false},
+		{expr: `.a != "1"`, data: `{"a":1}`, want: true},
+		{expr: `.a < "1"`, data: `{"a":0}`, want: false},
+		{expr: `.a >= "1"`, data: `{"a":2}`, want: false},
+		{expr: `.a > null`, data: `{"a":1}`, want: false},
+		{expr: `.a == null`, data: `{}`, want: true},
+		{expr: `.a == true`, data: `{"a":true}`, want: true},
+		{expr: `.a == .b`, data: `{"a":{"x":[1]},"b":{"x":[1]}}`, want: true},
+		{expr: `.a <= "b"`, data: `{"a":"b"}`, want: true},
+		{expr: `.a > "B"`, data: `{"a":"a"}`, want: true},
+
+		// Unary minus and subtraction
+		{expr: `.a == -1`, data: `{"a":-1}`, want: true},
+		{expr: `.a == -.5`, data: `{"a":-0.5}`, want: true},
+		{expr: `-1 < .a`, data: `{"a":0}`, want: true},
+		{expr: `.a - 1 == 1`, data: `{"a":2}`, want: true},
+		{expr: `.a -1 == 1`, data: `{"a":2}`, want: true},
+		{expr: `.a-1 == 1`, data: `{"a":2}`, want: true},
+		{expr: `2 -1 == 1`, data: `{}`, want: true},
+		{expr: `(.a) -1 == 1`, data: `{"a":2}`, want: true},
+		{expr: `.a - -1 == 3`, data: `{"a":2}`, want: true},
+
+		// Arithmetic
+		{expr: `.a + .b * 2 == 7`, data: `{"a":1,"b":3}`, want: true},
+		{expr: `(.a + .b) * 2 == 8`, data: `{"a":1,"b":3}`, want: true},
+		{expr: `.a - .b - 1 == 0`, data: `{"a":4,"b":3}`, want: true},
+		{expr: `.a / .b == 2`, data: `{"a":4,"b":2}`, want: true},
+		{expr: `.a / 0 == null`, data: `{"a":4}`, want: true},
+		{expr: `.a + .b == "xy"`, data: `{"a":"x","b":"y"}`, want: true},
+		{expr: `.a + null == 1`, data: `{"a":1}`, want: true},
+		{expr: `null + .a == "x"`, data: `{"a":"x"}`, want: true},
+		{expr: `.a + 1 == null`, data: `{"a":"x"}`, want: true},
+		{expr: `.a - "x" == null`, data: `{"a":1}`, want: true},
+	} {
+		expr, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("parseFilter(%s) returned error: %s", tt.expr, err)
+			continue
+		}
+		if got := matchFilter(expr, json.RawMessage(tt.data)); got != tt.want {
+			t.Errorf("matchFilter(%s, %s) = %v; want = %v", tt.expr, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		want string
+	}{
+		{expr: `-.a`, want: `invalid number "-"`},
+		{expr: `.a == "abc`, want: `unterminated string "abc`},
+		{expr: `.a == "abc\"`, want: `unterminated string "abc\"`},
+		{expr: `.a == "\x"`, want: `invalid string "\x"`},
+		{expr: `.a = 1`, want: `invalid operator "="`},
+		{expr: `!.a`, want: `invalid operator "!"`},
+		{expr: `.a ==`, want: `unexpected end of expression`},
+		{expr: ``, want: `unexpected end of expression`},
+		{expr: `(.a == 1`, want: `missing )`},
+		{expr: `.a == 1)`, want: `unexpected ")"`},
+		{expr: `.a .b`, want: `unexpected ".b"`},
+		{expr: `.a == foo`, want: `unexpected "foo"`},
+		{expr: `.a[x] == 1`, want: `invalid index "x"`},
+		{expr: `.a[0 == 1`, want: `missing ] in path`},
+		{expr: `.a $ 1`, want: `unexpected character '$'`},
+		{expr: `.a == 1 €`, want: `unexpected character '€'`},
+		{expr: `select .a`, want: `missing ( after select`},
+		{expr: `select(.a`, want: `missing )`},
+		{expr: `select(.a) == 1)`, want: `unexpected ")"`},
+		{expr: `.a == 1e`, want: `invalid number "1e"`},
+		{expr: `.a == 1.`, want: `unexpected "."`},
+	} {
+		_, err := parseFilter(tt.expr)
+		if err == nil {
+			t.Errorf("parseFilter(%s) = nil; want error %q", tt.expr, tt.want)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("parseFilter(%s) = %q; want = %q", tt.expr, err, tt.want)
+		}
+	}
+}
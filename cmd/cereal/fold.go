@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+// reducer folds the events of an aggregate into its state. It's read from a
+// YAML or JSON file, such as
+//
+//	initial:
+//	  status: new
+//	  total: 0
+//	events:
+//	  OrderPlaced:
+//	    merge: .event
+//	  ItemAdded:
+//	    set:
+//	      total: .state.total + .event.price
+//	      items: .state.items + 1
+//	  OrderCancelled:
+//	    set:
+//	      status: '"cancelled"'
+//	    unset: [discount]
+//
+// Handlers are keyed by event type, or by a pattern such as Order*. Values
+// are filter expressions, evaluated against an object with the current state,
+// the event data, and the event id, type and version.
+type reducer struct {
+	Initial interface{}                `json:"initial"`
+	Events  map[string]*reducerHandler `json:"events"`
+
+	set   map[string]map[string]filterExpr
+	merge map[string]filterExpr
+}
+
+// reducerHandler describes how an event changes the state. Fields are set
+// before the merge, and unset last.
+type reducerHandler struct {
+	Merge string            `json:"merge"`
+	Set   map[string]string `json:"set"`
+	Unset []string          `json:"unset"`
+}
+
+// foldStep is the state of an aggregate after an event.
+type foldStep struct {
+	Version   int64                       `json:"version"`
+	EventID   string                      `json:"eventId"`
+	EventType string                      `json:"eventType"`
+	State     interface{}                 `json:"state"`
+	Changes   []serialized.PatchOperation `json:"changes"`
+}
+
+// loadReducer reads a reducer file.
+func loadReducer(filename string) (*reducer, error) {
+	var r reducer
+	if err := decodeFile(filename, &r); err != nil {
+		return nil, err
+	}
+
+	if err := r.compile(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// compile parses the expressions of the reducer.
+func (r *reducer) compile() error {
+	r.set = make(map[string]map[string]filterExpr)
+	r.merge = make(map[string]filterExpr)
+
+	for eventType, h := range r.Events {
+		if _, err := path.Match(eventType, ""); err != nil {
+			return fmt.Errorf("invalid event type pattern %q: %s", eventType, err)
+		}
+		if h == nil {
+			return fmt.Errorf("%s: missing handler", eventType)
+		}
+
+		if h.Merge != "" {
+			expr, err := parseFilter(h.Merge)
+			if err != nil {
+				return fmt.Errorf("%s: invalid merge expression: %s", eventType, err)
+			}
+			r.merge[eventType] = expr
+		}
+
+		r.set[eventType] = make(map[string]filterExpr)
+		for field, s := range h.Set {
+			expr, err := parseFilter(s)
+			if err != nil {
+				return fmt.Errorf("%s: invalid expression for %s: %s", eventType, field, err)
+			}
+			r.set[eventType][field] = expr
+		}
+	}
+
+	return nil
+}
+
+// handler returns the name of the handler for an event type. Exact matches
+// take precedence over patterns, which are tried in alphabetical order.
+func (r *reducer) handler(eventType string) (string, bool) {
+	if _, ok := r.Events[eventType]; ok {
+		return eventType, true
+	}
+
+	var patterns []string
+	for p := range r.Events {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, eventType); ok {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// apply returns the state after an event. The state passed to it is never
+// modified.
+func (r *reducer) apply(state interface{}, version int64, ev *serialized.Event) (interface{}, error) {
+	name, ok := r.handler(ev.Type)
+	if !ok {
+		return state, nil
+	}
+
+	var data interface{}
+	if len(ev.Data) > 0 {
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return nil, fmt.Errorf("event %s: invalid data: %s", ev.ID, err)
+		}
+	}
+
+	input := map[string]interface{}{
+		"state":   state,
+		"event":   data,
+		"id":      ev.ID,
+		"type":    ev.Type,
+		"version": float64(version),
+	}
+
+	next := copyJSON(state)
+
+	// Every expression sees the state from before the event, regardless of
+	// the order the fields are set in.
+	fields := make([]string, 0, len(r.set[name]))
+	for field := range r.set[name] {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// The value of an expression may be part of the state before the event,
+	// so it's copied before it's changed by the merge or unset.
+	for _, field := range fields {
+		next = setField(next, strings.Split(field, "."), copyJSON(r.set[name][field].eval(input)))
+	}
+
+	if expr, ok := r.merge[name]; ok {
+		next = mergePatch(next, copyJSON(expr.eval(input)))
+	}
+
+	for _, field := range r.Events[name].Unset {
+		next = unsetField(next, strings.Split(field, "."))
+	}
+
+	return next, nil
+}
+
+// fold applies the events to the initial state, where first is the version
+// of the first event.
+func (r *reducer) fold(events []*serialized.Event, first int64) ([]*foldStep, error) {
+	state := copyJSON(r.Initial)
+
+	var steps []*foldStep
+	for i, ev := range events {
+		version := first + int64(i)
+
+		next, err := r.apply(state, version, ev)
+		if err != nil {
+			return nil, err
+		}
+
+		changes, err := diffStates(state, next)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, &foldStep{
+			Version:   version,
+			EventID:   ev.ID,
+			EventType: ev.Type,
+			State:     next,
+			Changes:   changes,
+		})
+
+		state = next
+	}
+
+	return steps, nil
+}
+
+// diffStates returns the JSON Patch operations that turn the state a into b.
+func diffStates(a, b interface{}) ([]serialized.PatchOperation, error) {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return serialized.DiffJSON(ab, bb)
+}
+
+// firstVersion returns the version of the first loaded event of an
+// aggregate. The version of an aggregate is the version of its last event,
+// which is only the number of events if all of them were loaded.
+func firstVersion(agg *serialized.Aggregate) int64 {
+	if agg.Version < int64(len(agg.Events)) {
+		return 1
+	}
+	return agg.Version - int64(len(agg.Events)) + 1
+}
+
+// eventsAt returns the events up to and including the given version, where
+// first is the version of the first event. A version of 0 means all events.
+func eventsAt(events []*serialized.Event, first, version int64) ([]*serialized.Event, error) {
+	if version <= 0 {
+		return events, nil
+	}
+	if version < first {
+		return nil, fmt.Errorf("events before version %d aren't available", first)
+	}
+	if last := first + int64(len(events)) - 1; version > last {
+		return nil, fmt.Errorf("aggregate is at version %d", last)
+	}
+	return events[:version-first+1], nil
+}
+
+// setField sets the value at the path, creating objects along the way.
+func setField(v interface{}, path []string, value interface{}) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	m[path[0]] = setField(m[path[0]], path[1:], value)
+
+	return m
+}
+
+// unsetField removes the value at the path, if it exists.
+func unsetField(v interface{}, path []string) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(path) == 0 {
+		return v
+	}
+
+	if len(path) == 1 {
+		delete(m, path[0])
+	} else if child, ok := m[path[0]]; ok {
+		m[path[0]] = unsetField(child, path[1:])
+	}
+
+	return m
+}
+
+// mergePatch applies a JSON Merge Patch (RFC 7396) to v.
+func mergePatch(v, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+
+	for k, pv := range p {
+		if pv == nil {
+			delete(m, k)
+			continue
+		}
+		m[k] = mergePatch(m[k], pv)
+	}
+
+	return m
+}
+
+// copyJSON returns a deep copy of a decoded JSON value.
+func copyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyJSON(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = copyJSON(e)
+		}
+		return a
+	}
+	return v
+}
+
+func aggregatesFoldHandler(c *serialized.Client, out *printer, aggType, aggID, reducerFile string, atVersion int64, steps bool) error {
+	r, err := loadReducer(reducerFile)
+	if err != nil {
+		return err
+	}
+
+	agg, err := c.LoadAggregate(context.Background(), aggType, aggID)
+	if err != nil {
+		return err
+	}
+
+	first := firstVersion(agg)
+
+	events, err := eventsAt(agg.Events, first, atVersion)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.fold(events, first)
+	if err != nil {
+		return err
+	}
+
+	if steps {
+		return out.Print(result, func(w *tabwriter.Writer) error {
+			for _, s := range result {
+				fmt.Fprintf(w, "Version %d\t%s\t%s\n", s.Version, s.EventType, s.EventID)
+				for _, op := range s.Changes {
+					fmt.Fprintf(w, "  %s\t%s\t%s\n", op.Op, op.Path, string(op.Value))
+				}
+				if len(s.Changes) == 0 {
+					fmt.Fprintln(w, "  (no changes)")
+				}
+			}
+			return nil
+		})
+	}
+
+	state, version := copyJSON(r.Initial), first-1
+	if len(result) > 0 {
+		state, version = result[len(result)-1].State, result[len(result)-1].Version
+	}
+
+	return out.Print(state, func(w *tabwriter.Writer) error {
+		b, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Version:\t%d\n", version)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, string(b))
+		return nil
+	})
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func newTestReducer(t *testing.T, initial string, events map[string]*reducerHandler) *reducer {
+	t.Helper()
+
+	r := &reducer{Events: events}
+	if initial != "" {
+		if err := json.Unmarshal([]byte(initial), &r.Initial); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func mustMarshal(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+func TestReducerFold(t *testing.T) {
+	r := newTestReducer(t, `{"status":"new","total":0}`, map[string]*reducerHandler{
+		"OrderPlaced": {Merge: ".event"},
+		"Item*": {Set: map[string]string{
+			"total": ".state.total + .event.price * .event.quantity",
+			"items": ".state.items + 1",
+		}},
+		"ItemRemoved": {Set: map[string]string{
+			"total": ".state.total - .event.price",
+		}},
+		"OrderCancelled": {
+			Set:   map[string]string{"status": `"cancelled"`},
+			Unset: []string{"customer.email"},
+		},
+	})
+
+	events := []*serialized.Event{
+		{ID: "1", Type: "OrderPlaced", Data: json.RawMessage(`{"status":"placed","customer":{"name":"a","email":"a@example.com"}}`)},
+		{ID: "2", Type: "ItemAdded", Data: json.RawMessage(`{"price":10,"quantity":3}`)},
+		{ID: "3", Type: "ItemRemoved", Data: json.RawMessage(`{"price":10}`)},
+		{ID: "4", Type: "OrderNoted"},
+		{ID: "5", Type: "OrderCancelled"},
+	}
+
+	steps, err := r.fold(events, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != len(events) {
+		t.Fatalf("unexpected number of steps = %d; want = %d", len(steps), len(events))
+	}
+	if steps[0].Version != 3 || steps[4].Version != 7 {
+		t.Errorf("versions = %d-%d; want = %d-%d", steps[0].Version, steps[4].Version, 3, 7)
+	}
+
+	for i, want := range []string{
+		`{"customer":{"email":"a@example.com","name":"a"},"status":"placed","total":0}`,
+		`{"customer":{"email":"a@example.com","name":"a"},"items":1,"status":"placed","total":30}`,
+		`{"customer":{"email":"a@example.com","name":"a"},"items":1,"status":"placed","total":20}`,
+		`{"customer":{"email":"a@example.com","name":"a"},"items":1,"status":"placed","total":20}`,
+		`{"customer":{"name":"a"},"items":1,"status":"cancelled","total":20}`,
+	} {
+		if got := mustMarshal(steps[i].State); got != want {
+			t.Errorf("state at version %d = %s; want = %s", steps[i].Version, got, want)
+		}
+	}
+
+	for i, want := range [][]serialized.PatchOperation{
+		{
+			{Op: "add", Path: "/customer", Value: json.RawMessage(`{"email":"a@example.com","name":"a"}`)},
+			{Op: "replace", Path: "/status", Value: json.RawMessage(`"placed"`)},
+		},
+		{
+			{Op: "add", Path: "/items", Value: json.RawMessage(`1`)},
+			{Op: "replace", Path: "/total", Value: json.RawMessage(`30`)},
+		},
+		{
+			{Op: "replace", Path: "/total", Value: json.RawMessage(`20`)},
+		},
+		nil,
+		{
+			{Op: "remove", Path: "/customer/email"},
+			{Op: "replace", Path: "/status", Value: json.RawMessage(`"cancelled"`)},
+		},
+	} {
+		if got := steps[i].Changes; !reflect.DeepEqual(got, want) {
+			t.Errorf("changes at version %d = %s; want = %s", steps[i].Version, mustMarshal(got), mustMarshal(want))
+		}
+	}
+
+	// The initial state is never modified.
+	if got, want := mustMarshal(r.Initial), `{"status":"new","total":0}`; got != want {
+		t.Errorf("initial state = %s; want = %s", got, want)
+	}
+}
+
+func TestReducerApplyOrder(t *testing.T) {
+	r := newTestReducer(t, ``, map[string]*reducerHandler{
+		"Changed": {
+			Set: map[string]string{
+				"a":     ".state.b",
+				"b":     ".state.a",
+				"c":     "1",
+				"d":     "1",
+				"x.y":   ".version",
+				"x.old": ".type + \"/\" + .id",
+			},
+			Merge: `.event`,
+			Unset: []string{"d", "missing.field"},
+		},
+	})
+
+	state := map[string]interface{}{"a": "a", "b": "b"}
+
+	got, err := r.apply(state, 3, &serialized.Event{
+		ID:   "1",
+		Type: "Changed",
+		Data: json.RawMessage(`{"c":2,"d":2,"b":null}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields are set from the state before the event, then the merge is
+	// applied, and the fields are unset last.
+	want := `{"a":"b","c":2,"x":{"old":"Changed/1","y":3}}`
+	if mustMarshal(got) != want {
+		t.Fatalf("got = %s; want = %s", mustMarshal(got), want)
+	}
+
+	if mustMarshal(state) != `{"a":"a","b":"b"}` {
+		t.Fatalf("state was modified: %s", mustMarshal(state))
+	}
+}
+
+func TestReducerHandler(t *testing.T) {
+	r := newTestReducer(t, ``, map[string]*reducerHandler{
+		"ItemAdded": {},
+		"Item*":     {},
+		"I*":        {},
+		"Order?":    {},
+	})
+
+	for _, tt := range []struct {
+		eventType string
+		want      string
+		ok        bool
+	}{
+		{eventType: "ItemAdded", want: "ItemAdded", ok: true},
+		{eventType: "ItemRemoved", want: "I*", ok: true},
+		{eventType: "Item*", want: "Item*", ok: true},
+		{eventType: "Orders", want: "Order?", ok: true},
+		{eventType: "OrderPlaced", want: "", ok: false},
+	} {
+		got, ok := r.handler(tt.eventType)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("handler(%s) = %q, %v; want = %q, %v", tt.eventType, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReducerCompileErrors(t *testing.T) {
+	for _, tt := range []struct {
+		events map[string]*reducerHandler
+		want   string
+	}{
+		{
+			events: map[string]*reducerHandler{"Item[": {}},
+			want:   `invalid event type pattern "Item[": syntax error in pattern`,
+		},
+		{
+			events: map[string]*reducerHandler{"ItemAdded": nil},
+			want:   `ItemAdded: missing handler`,
+		},
+		{
+			events: map[string]*reducerHandler{"ItemAdded": {Merge: ".a ="}},
+			want:   `ItemAdded: invalid merge expression: invalid operator "="`,
+		},
+		{
+			events: map[string]*reducerHandler{"ItemAdded": {Set: map[string]string{"total": "-.a"}}},
+			want:   `ItemAdded: invalid expression for total: invalid number "-"`,
+		},
+	} {
+		r := &reducer{Events: tt.events}
+		if err := r.compile(); err == nil || err.Error() != tt.want {
+			t.Errorf("compile() = %v; want = %q", err, tt.want)
+		}
+	}
+}
+
+func TestEventsAt(t *testing.T) {
+	events := []*serialized.Event{{ID: "3"}, {ID: "4"}, {ID: "5"}}
+
+	for _, tt := range []struct {
+		version int64
+		want    int
+		err     string
+	}{
+		{version: 0, want: 3},
+		{version: 3, want: 1},
+		{version: 5, want: 3},
+		{version: 2, err: "events before version 3 aren't available"},
+		{version: 6, err: "aggregate is at version 5"},
+	} {
+		got, err := eventsAt(events, 3, tt.version)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("eventsAt(%d) = %v; want error %q", tt.version, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("eventsAt(%d) returned %d events; want = %d", tt.version, len(got), tt.want)
+		}
+	}
+}
+
+func TestFirstVersion(t *testing.T) {
+	events := []*serialized.Event{{ID: "1"}, {ID: "2"}}
+
+	for _, tt := range []struct {
+		version int64
+		want    int64
+	}{
+		{version: 2, want: 1},
+		{version: 5, want: 4},
+		{version: 0, want: 1},
+	} {
+		agg := &serialized.Aggregate{Version: tt.version, Events: events}
+		if got := firstVersion(agg); got != tt.want {
+			t.Errorf("firstVersion at version %d = %d; want = %d", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestAggregatesFoldSteps(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"aggregateId": "a",
+			"aggregateType": "order",
+			"aggregateVersion": 2,
+			"events": [
+				{"eventId": "1", "eventType": "OrderPlaced", "data": {"customer": {"name": "a", "email": "a@example.com"}}},
+				{"eventId": "2", "eventType": "OrderShipped"}
+			]
+		}`))
+	}))
+	defer ts.Close()
+
+	// The shipping address is set to the customer object of the previous
+	// state, and then changed.
+	reducerFile := filepath.Join(t.TempDir(), "reducer.json")
+	err := ioutil.WriteFile(reducerFile, []byte(`{
+		"events": {
+			"OrderPlaced": {"merge": ".event"},
+			"OrderShipped": {"set": {"shipTo": ".state.customer"}, "unset": ["shipTo.email"]}
+		}
+	}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	out, err := newPrinter(&buf, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := serialized.NewClient(serialized.WithBaseURL(ts.URL))
+	if err := aggregatesFoldHandler(c, out, "order", "a", reducerFile, 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var steps []struct {
+		State interface{} `json:"state"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &steps); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{
+		`{"customer":{"email":"a@example.com","name":"a"}}`,
+		`{"customer":{"email":"a@example.com","name":"a"},"shipTo":{"name":"a"}}`,
+	} {
+		if got := mustMarshal(steps[i].State); got != want {
+			t.Errorf("state at step %d = %s; want = %s", i+1, got, want)
+		}
+	}
+}
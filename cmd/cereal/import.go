@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/google/uuid"
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+// importRecord is a set of events to store for an aggregate. Records that
+// fail to import are written to the failure report in the same format,
+// along with the error.
+type importRecord struct {
+	AggregateType   string              `json:"aggregateType"`
+	AggregateID     string              `json:"aggregateId"`
+	ExpectedVersion int64               `json:"expectedVersion,omitempty"`
+	Events          []*serialized.Event `json:"events"`
+	Error           string              `json:"error,omitempty"`
+}
+
+// importGroup holds all events to import for one aggregate. The batches of
+// a group are stored in order, while groups are imported concurrently.
+type importGroup struct {
+	*importRecord
+
+	batches [][]*serialized.Event
+}
+
+func eventsImportHandler(c *serialized.Client, files []string, batchSize, concurrency int, reportPath string, resume bool) error {
+	// Stop on interrupt, rather than exit, so that the failure report is
+	// written for the events that haven't been imported.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return importEvents(ctx, c, files, batchSize, concurrency, reportPath, resume)
+}
+
+// importEvents imports the records in the given files. Records that fail,
+// or aren't imported before ctx is done, are written to the failure report.
+func importEvents(ctx context.Context, c *serialized.Client, files []string, batchSize, concurrency int, reportPath string, resume bool) error {
+	if batchSize < 1 {
+		return errors.New("batch size must be at least 1")
+	}
+	if concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+
+	if resume {
+		if _, err := os.Stat(reportPath); os.IsNotExist(err) {
+			return fmt.Errorf("no failure report found at %s", reportPath)
+		}
+		files = []string{reportPath}
+	} else if _, err := os.Stat(reportPath); err == nil {
+		return fmt.Errorf("failure report %s already exists. Use --resume to retry it, or remove it to start over", reportPath)
+	}
+
+	records, err := readImportRecords(files)
+	if err != nil {
+		return err
+	}
+
+	groups, total, err := groupImportRecords(records, batchSize)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		fmt.Println("No events to import.")
+		return nil
+	}
+
+	var (
+		mu       sync.Mutex
+		imported int
+		failed   []*importRecord
+		bar      = newProgressBar(os.Stderr)
+	)
+
+	progress := func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		imported += n
+		bar.Update(float64(imported)/float64(total)*100, fmt.Sprintf("(%d/%d events)", imported, total))
+	}
+
+	work := make(chan *importGroup)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range work {
+				if rec := importAggregate(ctx, c, g, progress); rec != nil {
+					mu.Lock()
+					failed = append(failed, rec)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	progress(0)
+	for _, g := range groups {
+		work <- g
+	}
+	close(work)
+	wg.Wait()
+	bar.Done()
+
+	if err := writeImportReport(reportPath, failed, resume); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d of %d events to %d aggregates.\n", imported, total, len(groups)-len(failed))
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("import was interrupted, see %s. Use --resume to import the remaining events", reportPath)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d aggregates failed to import, see %s. Use --resume to retry them", len(failed), reportPath)
+	}
+
+	return nil
+}
+
+// importAggregate stores the batches of a group in order. If a batch fails,
+// or ctx is done, the remaining events of the group are returned as a failed
+// record.
+func importAggregate(ctx context.Context, c *serialized.Client, g *importGroup, progress func(int)) *importRecord {
+	version := g.ExpectedVersion
+
+	for i, batch := range g.batches {
+		err := ctx.Err()
+		if err == nil {
+			err = c.Store(ctx, g.AggregateType, g.AggregateID, version, batch...)
+		}
+		if err != nil {
+			var remaining []*serialized.Event
+			for _, b := range g.batches[i:] {
+				remaining = append(remaining, b...)
+			}
+
+			return &importRecord{
+				AggregateType:   g.AggregateType,
+				AggregateID:     g.AggregateID,
+				ExpectedVersion: version,
+				Events:          remaining,
+				Error:           err.Error(),
+			}
+		}
+
+		if version > 0 {
+			version += int64(len(batch))
+		}
+
+		progress(len(batch))
+	}
+
+	return nil
+}
+
+// groupImportRecords merges the records of each aggregate, in the order they
+// first appear, and splits their events into batches. Events without an ID
+// are given one, so that a failure report can be resumed without creating
+// duplicate events. It returns the groups and the total number of events.
+//
+// Only the first record of an aggregate may set an expected version. Later
+// records either leave it out, or repeat the same one.
+func groupImportRecords(records []*importRecord, batchSize int) ([]*importGroup, int, error) {
+	var (
+		groups []*importGroup
+		byKey  = make(map[string]*importGroup)
+		total  int
+	)
+
+	for _, r := range records {
+		key := r.AggregateType + "/" + r.AggregateID
+
+		g, ok := byKey[key]
+		if !ok {
+			g = &importGroup{importRecord: &importRecord{
+				AggregateType:   r.AggregateType,
+				AggregateID:     r.AggregateID,
+				ExpectedVersion: r.ExpectedVersion,
+			}}
+			byKey[key] = g
+			groups = append(groups, g)
+		} else if r.ExpectedVersion != 0 && r.ExpectedVersion != g.ExpectedVersion {
+			return nil, 0, fmt.Errorf("aggregate %s has conflicting expected versions %d and %d", key, g.ExpectedVersion, r.ExpectedVersion)
+		}
+
+		for _, ev := range r.Events {
+			if ev.ID == "" {
+				ev.ID = uuid.New().String()
+			}
+		}
+
+		g.Events = append(g.Events, r.Events...)
+		total += len(r.Events)
+	}
+
+	for _, g := range groups {
+		for i := 0; i < len(g.Events); i += batchSize {
+			end := i + batchSize
+			if end > len(g.Events) {
+				end = len(g.Events)
+			}
+			g.batches = append(g.batches, g.Events[i:end])
+		}
+	}
+
+	return groups, total, nil
+}
+
+// readImportRecords reads records from the given files, or from stdin if no
+// files are given or a file is "-".
+func readImportRecords(files []string) ([]*importRecord, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var records []*importRecord
+
+	for _, name := range files {
+		recs, err := readImportFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, recs...)
+	}
+
+	return records, nil
+}
+
+// readImportFile reads the records in a single file, or stdin if the name is
+// "-".
+func readImportFile(name string) ([]*importRecord, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	records, err := decodeImportRecords(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	return records, nil
+}
+
+// decodeImportRecords decodes either a JSON array of records, or one record
+// per line.
+func decodeImportRecords(r io.Reader) ([]*importRecord, error) {
+	br := bufio.NewReader(r)
+
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			break
+		}
+		br.ReadByte()
+	}
+
+	dec := json.NewDecoder(br)
+
+	b, _ := br.Peek(1)
+	if b[0] == '[' {
+		var records []*importRecord
+		if err := dec.Decode(&records); err != nil {
+			return nil, err
+		}
+		return records, validateImportRecords(records)
+	}
+
+	var records []*importRecord
+	for {
+		var rec importRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("record %d: %s", len(records)+1, err)
+		}
+		records = append(records, &rec)
+	}
+
+	return records, validateImportRecords(records)
+}
+
+func validateImportRecords(records []*importRecord) error {
+	for i, r := range records {
+		if r.AggregateType == "" {
+			return fmt.Errorf("record %d: missing aggregateType", i+1)
+		}
+		if r.AggregateID == "" {
+			return fmt.Errorf("record %d: missing aggregateId", i+1)
+		}
+		for _, ev := range r.Events {
+			if ev.Type == "" {
+				return fmt.Errorf("record %d: event is missing eventType", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// writeImportReport writes the failed records as NDJSON, so that they can be
+// imported again. When resuming, the report is replaced by the records that
+// failed again, or removed if there are none.
+func writeImportReport(path string, failed []*importRecord, resume bool) error {
+	if len(failed) == 0 {
+		if !resume {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range failed {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
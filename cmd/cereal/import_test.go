@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func TestGroupImportRecords(t *testing.T) {
+	records := []*importRecord{
+		{AggregateType: "order", AggregateID: "a", ExpectedVersion: 2, Events: []*serialized.Event{{ID: "1", Type: "A"}, {ID: "2", Type: "B"}}},
+		{AggregateType: "order", AggregateID: "b", Events: []*serialized.Event{{Type: "A"}}},
+		{AggregateType: "order", AggregateID: "a", Events: []*serialized.Event{{ID: "3", Type: "C"}}},
+		{AggregateType: "order", AggregateID: "a", ExpectedVersion: 2, Events: []*serialized.Event{{ID: "4", Type: "D"}}},
+	}
+
+	groups, total, err := groupImportRecords(records, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 5 {
+		t.Fatalf("total = %d; want = %d", total, 5)
+	}
+	if len(groups) != 2 || groups[0].AggregateID != "a" || groups[1].AggregateID != "b" {
+		t.Fatalf("unexpected number of groups = %d; want = %d", len(groups), 2)
+	}
+
+	a := groups[0]
+	if a.ExpectedVersion != 2 {
+		t.Errorf("expected version = %d; want = %d", a.ExpectedVersion, 2)
+	}
+
+	var batches []string
+	for _, b := range a.batches {
+		var ids []string
+		for _, ev := range b {
+			ids = append(ids, ev.ID)
+		}
+		batches = append(batches, strings.Join(ids, ","))
+	}
+	if got, want := strings.Join(batches, " "), "1,2,3 4"; got != want {
+		t.Errorf("batches = %s; want = %s", got, want)
+	}
+
+	if id := groups[1].Events[0].ID; id == "" {
+		t.Errorf("event without id wasn't given one")
+	}
+}
+
+func TestGroupImportRecordsConflictingVersions(t *testing.T) {
+	for name, versions := range map[string][2]int64{
+		"different": {2, 3},
+		"later":     {0, 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			records := []*importRecord{
+				{AggregateType: "order", AggregateID: "a", ExpectedVersion: versions[0], Events: []*serialized.Event{{ID: "1", Type: "A"}}},
+				{AggregateType: "order", AggregateID: "a", ExpectedVersion: versions[1], Events: []*serialized.Event{{ID: "2", Type: "B"}}},
+			}
+
+			if _, _, err := groupImportRecords(records, 10); err == nil {
+				t.Fatal("expected error for conflicting expected versions")
+			}
+		})
+	}
+}
+
+func TestEventsImportResume(t *testing.T) {
+	type request struct {
+		AggregateID     string              `json:"aggregateId"`
+		ExpectedVersion int64               `json:"expectedVersion"`
+		Events          []*serialized.Event `json:"events"`
+	}
+
+	var (
+		mu      sync.Mutex
+		stored  []request
+		failing = true
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failing && req.Events[0].ID == "3" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		stored = append(stored, req)
+	}))
+	defer ts.Close()
+
+	c := serialized.NewClient(serialized.WithBaseURL(ts.URL))
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "events.ndjson")
+	report := filepath.Join(dir, "failures.ndjson")
+
+	err := ioutil.WriteFile(input, []byte(strings.Join([]string{
+		`{"aggregateType":"order","aggregateId":"a","expectedVersion":1,"events":[{"eventId":"1","eventType":"A"},{"eventId":"2","eventType":"B"},{"eventId":"3","eventType":"C"}]}`,
+		`{"aggregateType":"order","aggregateId":"b","events":[{"eventId":"4","eventType":"A"}]}`,
+	}, "\n")), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := eventsImportHandler(c, []string{input}, 2, 1, report, false); err == nil {
+		t.Fatal("expected error for failed aggregate")
+	}
+
+	records, err := readImportRecords([]string{report})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("unexpected number of failed records = %d; want = %d", len(records), 1)
+	}
+	if rec := records[0]; rec.AggregateID != "a" || len(rec.Events) != 1 || rec.ExpectedVersion != 3 {
+		t.Fatalf("unexpected failed record = %+v", rec)
+	}
+
+	// A new import doesn't overwrite the failure report.
+	if err := eventsImportHandler(c, []string{input}, 2, 1, report, false); err == nil {
+		t.Fatal("expected error for existing failure report")
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	if err := eventsImportHandler(c, nil, 2, 1, report, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(report); !os.IsNotExist(err) {
+		t.Fatalf("failure report wasn't removed")
+	}
+
+	var got []string
+	for _, req := range stored {
+		for _, ev := range req.Events {
+			got = append(got, req.AggregateID+"/"+ev.ID)
+		}
+	}
+	if got, want := strings.Join(got, ","), "a/1,a/2,b/4,a/3"; got != want {
+		t.Fatalf("stored events = %s; want = %s", got, want)
+	}
+	if last := stored[len(stored)-1]; last.ExpectedVersion != 3 {
+		t.Fatalf("expected version = %d; want = %d", last.ExpectedVersion, 3)
+	}
+}
+
+func TestEventsImportInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		stored []string
+	)
+
+	// The first aggregate is stored, and the import is interrupted while
+	// storing the second one.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			AggregateID string `json:"aggregateId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(stored) > 0 {
+			cancel()
+			return
+		}
+		stored = append(stored, req.AggregateID)
+	}))
+	defer ts.Close()
+
+	c := serialized.NewClient(serialized.WithBaseURL(ts.URL))
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "events.ndjson")
+	report := filepath.Join(dir, "failures.ndjson")
+
+	err := ioutil.WriteFile(input, []byte(strings.Join([]string{
+		`{"aggregateType":"order","aggregateId":"a","events":[{"eventId":"1","eventType":"A"}]}`,
+		`{"aggregateType":"order","aggregateId":"b","events":[{"eventId":"2","eventType":"A"}]}`,
+		`{"aggregateType":"order","aggregateId":"c","events":[{"eventId":"3","eventType":"A"}]}`,
+	}, "\n")), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := importEvents(ctx, c, []string{input}, 1, 1, report, false); err == nil {
+		t.Fatal("expected error for interrupted import")
+	}
+
+	records, err := readImportRecords([]string{report})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.AggregateID)
+	}
+	if got, want := strings.Join(got, ","), "b,c"; got != want {
+		t.Fatalf("failed records = %s; want = %s (stored %v)", got, want, stored)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kingpin"
 	serialized "github.com/marcusolsson/serialized-go"
@@ -11,105 +12,287 @@ func main() {
 	var (
 		app = kingpin.New("serialized-cli", "Interact with the Serialized.io API from the command-line.").Version("0.1.0")
 
+		// complete completes resource names. It's set up with the profile
+		// and base URL flags below, which are set before hints are requested.
+		complete = new(completer)
+
+		output      = app.Flag("output", "Output format: table, json, yaml, ndjson or template=<template>.").Short('o').Default("table").String()
+		profileName = app.Flag("profile", "Configuration profile to use.").Short('p').Envar("CEREAL_PROFILE").HintAction(complete.profiles).String()
+		baseURL     = app.Flag("base-url", "Base URL of the Serialized.io API.").String()
+
+		configCmd            = app.Command("config", "Configuration commands.")
+		configSet            = configCmd.Command("set", "Set a profile setting. Access keys are read from stdin.")
+		configSetKey         = configSet.Arg("key", "One of access-key, secret-access-key, base-url or poll-interval.").Required().Enum(configKeys...)
+		configSetValue       = configSet.Arg("value", "Value of the setting.").String()
+		configGet            = configCmd.Command("get", "Show the settings of a profile.")
+		configGetShowSecrets = configGet.Flag("show-secrets", "Show access keys.").Bool()
+		configList           = configCmd.Command("list", "List profiles.")
+		configUseProfile     = configCmd.Command("use-profile", "Set the profile used by default.")
+		configUseProfileName = configUseProfile.Arg("name", "Name of the profile.").HintAction(complete.profiles).Required().String()
+
 		events = app.Command("events", "Event commands.")
 
 		eventsStore                = events.Command("store", "Store a new event.")
-		eventsStoreAggType         = eventsStore.Flag("agg-type", "Type of aggregate.").Short('a').Required().String()
+		eventsStoreAggType         = eventsStore.Flag("agg-type", "Type of aggregate.").Short('a').HintAction(complete.feeds).Required().String()
 		eventsStoreAggID           = eventsStore.Flag("agg-id", "ID of aggregate.").String()
 		eventsStoreEventType       = eventsStore.Flag("event-type", "Type of event.").Short('e').Required().String()
 		eventsStoreEventID         = eventsStore.Flag("event-id", "ID of event.").String()
 		eventsStoreData            = eventsStore.Flag("data", "Event data.").Short('d').Required().String()
 		eventsStoreExpectedVersion = eventsStore.Flag("expected-version", "Version number for optimistic concurrency control.").Int64()
 
+		eventsImport            = events.Command("import", "Import events from NDJSON or JSON array files, or stdin.")
+		eventsImportFiles       = eventsImport.Arg("files", "Files to import. Reads from stdin if omitted.").Strings()
+		eventsImportBatchSize   = eventsImport.Flag("batch-size", "Max number of events to store per request.").Default("100").Int()
+		eventsImportConcurrency = eventsImport.Flag("concurrency", "Number of aggregates to import concurrently.").Short('c').Default("4").Int()
+		eventsImportReport      = eventsImport.Flag("report", "File to write failed records to.").Default("import-failures.ndjson").String()
+		eventsImportResume      = eventsImport.Flag("resume", "Retry the records in the failure report.").Bool()
+
+		completionCmd   = app.Command("completion", "Print a shell completion script.")
+		completionShell = completionCmd.Arg("shell", "One of bash, zsh or fish.").HintOptions("bash", "zsh", "fish").Required().Enum("bash", "zsh", "fish")
+
+		doctorCmd = app.Command("doctor", "Check connectivity, credentials and configuration, and print a report that's safe to share.")
+
+		uiCmd = app.Command("ui", "Browse feeds, aggregates, projections and reactions in a terminal UI.")
+
+		migrate           = app.Command("migrate", "Copy events between the accounts of two profiles.")
+		migrateFrom       = migrate.Flag("from-profile", "Profile of the account to copy events from.").HintAction(complete.profiles).Required().String()
+		migrateTo         = migrate.Flag("to-profile", "Profile of the account to copy events to.").HintAction(complete.profiles).Required().String()
+		migrateFeeds      = migrate.Flag("feed", "Feed to migrate. Can be repeated. Defaults to all feeds.").Short('f').HintAction(complete.feeds).Strings()
+		migrateEventTypes = migrate.Flag("event-type", "Event type to migrate. Can be repeated. Defaults to all event types.").Short('e').Strings()
+		migrateCheckpoint = migrate.Flag("checkpoint", "File to keep track of migrated feed entries, to allow resuming.").String()
+
 		aggregates = app.Command("aggregates", "Aggregate commands.")
 
-		aggregatesGet        = aggregates.Command("get", "Show aggregate")
-		aggregatesGetID      = aggregatesGet.Arg("id", "ID of aggregate.").Required().String()
-		aggregatesGetType    = aggregatesGet.Flag("type", "Type of aggregate.").Short('t').Required().String()
-		aggregatesGetLimit   = aggregatesGet.Flag("limit", "Max number of events to show in preview.").Short('l').Default("10").Int()
-		aggregatesDelete     = aggregates.Command("delete", "Delete aggregates of a given type")
-		aggregatesDeleteType = aggregatesDelete.Flag("type", "Type of aggregate.").Short('t').Required().String()
+		aggregatesGet           = aggregates.Command("get", "Show aggregate")
+		aggregatesGetID         = aggregatesGet.Arg("id", "ID of aggregate.").Required().String()
+		aggregatesGetType       = aggregatesGet.Flag("type", "Type of aggregate.").Short('t').HintAction(complete.feeds).Required().String()
+		aggregatesGetLimit      = aggregatesGet.Flag("limit", "Max number of events to show in preview.").Short('l').Default("10").Int()
+		aggregatesList          = aggregates.Command("list", "List aggregates of a given type.")
+		aggregatesListType      = aggregatesList.Flag("type", "Type of aggregate.").Short('t').HintAction(complete.feeds).Required().String()
+		aggregatesListSkip      = aggregatesList.Flag("skip", "Number of aggregates to skip.").Int()
+		aggregatesListLimit     = aggregatesList.Flag("limit", "Max number of aggregates to show.").Short('l').Default("20").Int()
+		aggregatesListSort      = aggregatesList.Flag("sort", "Field to sort by: aggregateId, aggregateVersion or lastUpdated. Prefix with - for descending order.").Short('s').String()
+		aggregatesFold          = aggregates.Command("fold", "Show the state of an aggregate, folded from its events.")
+		aggregatesFoldID        = aggregatesFold.Arg("id", "ID of aggregate.").Required().String()
+		aggregatesFoldType      = aggregatesFold.Flag("type", "Type of aggregate.").Short('t').HintAction(complete.feeds).Required().String()
+		aggregatesFoldReducer   = aggregatesFold.Flag("reducer", "Reducer file (YAML or JSON).").Short('r').Required().ExistingFile()
+		aggregatesFoldAtVersion = aggregatesFold.Flag("at-version", "Fold events up to and including this version.").Int64()
+		aggregatesFoldSteps     = aggregatesFold.Flag("steps", "Show how the state changed after each event.").Bool()
+		aggregatesDelete        = aggregates.Command("delete", "Delete all aggregates of a given type, or a single aggregate.")
+		aggregatesDeleteType    = aggregatesDelete.Flag("type", "Type of aggregate.").Short('t').HintAction(complete.feeds).Required().String()
+		aggregatesDeleteID      = aggregatesDelete.Flag("id", "ID of a single aggregate to delete.").String()
+		aggregatesDeleteYes     = aggregatesDelete.Flag("yes", "Delete without asking for confirmation.").Short('y').Bool()
+		aggregatesDeleteForce   = aggregatesDelete.Flag("force", "Same as --yes.").Short('f').Bool()
+		aggregatesDeleteDryRun  = aggregatesDelete.Flag("dry-run", "Show what would be deleted, without deleting it.").Bool()
 
 		feeds = app.Command("feeds", "Feed commands.")
 
 		feedsGet        = feeds.Command("get", "Show feed.")
-		feedsGetName    = feedsGet.Arg("name", "Name of feed.").Required().String()
+		feedsGetName    = feedsGet.Arg("name", "Name of feed.").HintAction(complete.feeds).Required().String()
 		feedsGetSince   = feedsGet.Flag("since", "Sequence number to start from.").Short('s').Int64()
 		feedsGetCurrent = feedsGet.Flag("current", "Return current sequence number at head for a given feed.").Short('c').Bool()
 		feedsList       = feeds.Command("list", "List all existing feeds.")
 
+		feedsTail            = feeds.Command("tail", "Follow a feed, printing events as they are stored.")
+		feedsTailName        = feedsTail.Arg("name", "Name of feed.").HintAction(complete.feeds).Required().String()
+		feedsTailEventTypes  = feedsTail.Flag("event-type", "Only show events of this type. Can be a glob, e.g. Order*, and repeated.").Short('e').Strings()
+		feedsTailAggregateID = feedsTail.Flag("aggregate-id", "Only show events of this aggregate.").Short('a').String()
+		feedsTailFilter      = feedsTail.Flag("filter", "Only show events whose data matches a jq-like expression, e.g. '.amount > 100'.").Short('f').String()
+		feedsTailShowData    = feedsTail.Flag("show-data", "Show event data.").Short('d').Bool()
+		feedsTailFromHead    = feedsTail.Flag("from-head", "Only show events stored from now on.").Bool()
+		feedsTailSince       = feedsTail.Flag("since", "Sequence number to start from.").Short('s').Int64()
+		feedsTailCount       = feedsTail.Flag("count", "Exit after showing this many events.").Short('n').Int()
+
+		feedsExport          = feeds.Command("export", "Export feed entries, with event data, to NDJSON files.")
+		feedsExportName      = feedsExport.Arg("name", "Name of feed.").HintAction(complete.feeds).Required().String()
+		feedsExportSince     = feedsExport.Flag("since", "Export entries after this sequence number.").Short('s').Int64()
+		feedsExportUntil     = feedsExport.Flag("until", "Export entries up to this sequence number. Defaults to the current head.").Short('u').Int64()
+		feedsExportOut       = feedsExport.Flag("out", "File to write to. Defaults to <name>.ndjson. The extension of the compression is added if missing.").String()
+		feedsExportCompress  = feedsExport.Flag("compress", "Compress files using gzip or zstd.").Enum("gzip", "zstd")
+		feedsExportChunkSize = feedsExport.Flag("chunk-size", "Split the export into files of this uncompressed size, e.g. 100MB.").Bytes()
+
 		projections = app.Command("projections", "Projection commands.")
 
 		projectionsSingle               = projections.Command("single", "Single projection commands.")
 		projectionsSingleGet            = projectionsSingle.Command("get", "Show projection.")
-		projectionsSingleGetName        = projectionsSingleGet.Arg("name", "Name of the projection.").Required().String()
+		projectionsSingleGetName        = projectionsSingleGet.Arg("name", "Name of the projection.").HintAction(complete.projections).Required().String()
 		projectionsSingleGetAggregateID = projectionsSingleGet.Flag("agg-id", "ID of aggregate.").Required().String()
+		projectionsSingleList           = projectionsSingle.Command("list", "List single projections.")
+		projectionsSingleListName       = projectionsSingleList.Arg("name", "Name of the projection.").HintAction(complete.projections).Required().String()
+		projectionsSingleListLimit      = projectionsSingleList.Flag("limit", "Max number of projections to show.").Short('l').Int()
+		projectionsSingleListSort       = projectionsSingleList.Flag("sort", "Field to sort by. Prefix with - for descending order.").Short('s').String()
 
 		projectionsAggregated        = projections.Command("aggregated", "Aggregated projection commands.")
 		projectionsAggregatedGet     = projectionsAggregated.Command("get", "Show aggregated projection.")
-		projectionsAggregatedGetName = projectionsAggregatedGet.Arg("name", "Name of the aggregated projection.").Required().String()
+		projectionsAggregatedGetName = projectionsAggregatedGet.Arg("name", "Name of the aggregated projection.").HintAction(complete.projections).Required().String()
 		projectionsAggregatedList    = projectionsAggregated.Command("list", "List aggregated projections.")
 
-		projectionsDefinitions           = projections.Command("definitions", "Projection definitions commands.")
-		projectionsDefinitionsGet        = projectionsDefinitions.Command("get", "Show projection definition.")
-		projectionsDefinitionsGetName    = projectionsDefinitionsGet.Arg("name", "Name of the projection definition.").Required().String()
-		projectionsDefinitionsDelete     = projectionsDefinitions.Command("delete", "Delete a projection definition.")
-		projectionsDefinitionsDeleteName = projectionsDefinitionsDelete.Arg("name", "Name of the projection definition.").Required().String()
-		projectionsDefinitionsList       = projectionsDefinitions.Command("list", "List projection definitions.")
+		projectionsDefinitions                   = projections.Command("definitions", "Projection definitions commands.")
+		projectionsDefinitionsGet                = projectionsDefinitions.Command("get", "Show projection definition.")
+		projectionsDefinitionsGetName            = projectionsDefinitionsGet.Arg("name", "Name of the projection definition.").HintAction(complete.projections).Required().String()
+		projectionsDefinitionsDelete             = projectionsDefinitions.Command("delete", "Delete a projection definition.")
+		projectionsDefinitionsDeleteName         = projectionsDefinitionsDelete.Arg("name", "Name of the projection definition.").HintAction(complete.projections).Required().String()
+		projectionsDefinitionsList               = projectionsDefinitions.Command("list", "List projection definitions.")
+		projectionsDefinitionsCreate             = projectionsDefinitions.Command("create", "Create a projection definition from a YAML or JSON file.")
+		projectionsDefinitionsCreateFile         = projectionsDefinitionsCreate.Flag("file", "Definition file. With --from-existing, the file to write the definition to.").Short('f').Required().String()
+		projectionsDefinitionsCreateReplace      = projectionsDefinitionsCreate.Flag("replace", "Replace the definition if it already exists.").Bool()
+		projectionsDefinitionsCreateFromExisting = projectionsDefinitionsCreate.Flag("from-existing", "Write an existing definition to the file, for editing.").PlaceHolder("NAME").HintAction(complete.projections).String()
+		projectionsDefinitionsRebuild            = projectionsDefinitions.Command("rebuild", "Rebuild a projection from the beginning of its feed.")
+		projectionsDefinitionsRebuildName        = projectionsDefinitionsRebuild.Arg("name", "Name of the projection definition.").HintAction(complete.projections).Required().String()
 
 		reactions = app.Command("reactions", "Reaction commands.")
 
-		reactionsDefinitions           = reactions.Command("definitions", "Reaction commands.")
-		reactionsDefinitionsGet        = reactionsDefinitions.Command("get", "Show reaction definition.")
-		reactionsDefinitionsGetName    = reactionsDefinitionsGet.Arg("name", "Name of the reaction definition").Required().String()
-		reactionsDefinitionsDelete     = reactionsDefinitions.Command("delete", "Delete a reaction definition.")
-		reactionsDefinitionsDeleteName = reactionsDefinitionsDelete.Arg("name", "Name of the reaction definition.").Required().String()
-		reactionsDefinitionsList       = reactionsDefinitions.Command("list", "List reaction definitions.")
-	)
+		reactionsDefinitions                   = reactions.Command("definitions", "Reaction commands.")
+		reactionsDefinitionsGet                = reactionsDefinitions.Command("get", "Show reaction definition.")
+		reactionsDefinitionsGetName            = reactionsDefinitionsGet.Arg("name", "Name of the reaction definition").HintAction(complete.reactions).Required().String()
+		reactionsDefinitionsDelete             = reactionsDefinitions.Command("delete", "Delete a reaction definition.")
+		reactionsDefinitionsDeleteName         = reactionsDefinitionsDelete.Arg("name", "Name of the reaction definition.").HintAction(complete.reactions).Required().String()
+		reactionsDefinitionsList               = reactionsDefinitions.Command("list", "List reaction definitions.")
+		reactionsDefinitionsCreate             = reactionsDefinitions.Command("create", "Create a reaction definition from a YAML or JSON file.")
+		reactionsDefinitionsCreateFile         = reactionsDefinitionsCreate.Flag("file", "Definition file. With --from-existing, the file to write the definition to.").Short('f').Required().String()
+		reactionsDefinitionsCreateReplace      = reactionsDefinitionsCreate.Flag("replace", "Replace the definition if it already exists.").Bool()
+		reactionsDefinitionsCreateFromExisting = reactionsDefinitionsCreate.Flag("from-existing", "Write an existing definition to the file, for editing.").PlaceHolder("NAME").HintAction(complete.reactions).String()
 
-	var (
-		accessKey       = os.Getenv("SERIALIZED_ACCESS_KEY")
-		secretAccessKey = os.Getenv("SERIALIZED_SECRET_ACCESS_KEY")
-	)
+		reactionsScheduled            = reactions.Command("scheduled", "Scheduled reaction commands.")
+		reactionsScheduledList        = reactionsScheduled.Command("list", "List scheduled reactions.")
+		reactionsScheduledListName    = reactionsScheduledList.Flag("name", "Name of the reaction definition.").Short('n').HintAction(complete.reactions).String()
+		reactionsScheduledListAggID   = reactionsScheduledList.Flag("agg-id", "ID of aggregate.").String()
+		reactionsScheduledListFrom    = reactionsScheduledList.Flag("from", "Only show reactions triggering after this time (RFC 3339).").String()
+		reactionsScheduledListTo      = reactionsScheduledList.Flag("to", "Only show reactions triggering before this time (RFC 3339).").String()
+		reactionsScheduledListSkip    = reactionsScheduledList.Flag("skip", "Number of reactions to skip.").Int()
+		reactionsScheduledListLimit   = reactionsScheduledList.Flag("limit", "Max number of reactions to show.").Short('l').Default("20").Int()
+		reactionsScheduledCancel      = reactionsScheduled.Command("cancel", "Cancel a scheduled reaction.")
+		reactionsScheduledCancelID    = reactionsScheduledCancel.Arg("id", "ID of the reaction.").Required().String()
+		reactionsTriggered            = reactions.Command("triggered", "Triggered reaction commands.")
+		reactionsTriggeredList        = reactionsTriggered.Command("list", "List triggered reactions.")
+		reactionsTriggeredListName    = reactionsTriggeredList.Flag("name", "Name of the reaction definition.").Short('n').HintAction(complete.reactions).String()
+		reactionsTriggeredListAggID   = reactionsTriggeredList.Flag("agg-id", "ID of aggregate.").String()
+		reactionsTriggeredListFrom    = reactionsTriggeredList.Flag("from", "Only show reactions triggered after this time (RFC 3339).").String()
+		reactionsTriggeredListTo      = reactionsTriggeredList.Flag("to", "Only show reactions triggered before this time (RFC 3339).").String()
+		reactionsTriggeredListSkip    = reactionsTriggeredList.Flag("skip", "Number of reactions to skip.").Int()
+		reactionsTriggeredListLimit   = reactionsTriggeredList.Flag("limit", "Max number of reactions to show.").Short('l').Default("20").Int()
+		reactionsTriggeredReexecute   = reactionsTriggered.Command("reexecute", "Execute a triggered reaction again.")
+		reactionsTriggeredReexecuteID = reactionsTriggeredReexecute.Arg("id", "ID of the reaction.").Required().String()
 
-	client := serialized.NewClient(
-		serialized.WithAccessKey(accessKey),
-		serialized.WithSecretAccessKey(secretAccessKey),
+		reactionsPreview      = reactions.Command("preview", "Show the request sent when a reaction is triggered by an event.")
+		reactionsPreviewName  = reactionsPreview.Arg("name", "Name of the reaction definition.").HintAction(complete.reactions).Required().String()
+		reactionsPreviewEvent = reactionsPreview.Flag("event", "File containing the event as JSON.").Short('e').Required().ExistingFile()
+		reactionsPreviewAggID = reactionsPreview.Flag("agg-id", "ID of aggregate.").String()
 	)
 
-	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
-	// Events
+	complete.profileName, complete.baseURL = profileName, baseURL
+
+	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	out, err := newPrinter(os.Stdout, *output)
+	kingpin.FatalIfError(err, "invalid --output")
+
+	var client *serialized.Client
+	if !strings.HasPrefix(cmd, configCmd.FullCommand()) && cmd != completionCmd.FullCommand() && cmd != doctorCmd.FullCommand() {
+		cfg, err := loadConfig()
+		kingpin.FatalIfError(err, "unable to load config")
+
+		opts, err := clientOptions(cfg, *profileName, *baseURL)
+		kingpin.FatalIfError(err, "invalid configuration")
+
+		client = serialized.NewClient(opts...)
+	}
+
+	switch cmd {
+	// Config
+	case configSet.FullCommand():
+		kingpin.FatalIfError(
+			configSetHandler(*profileName, *configSetKey, *configSetValue),
+			"unable to set config")
+	case configGet.FullCommand():
+		kingpin.FatalIfError(
+			configGetHandler(out, *profileName, *configGetShowSecrets),
+			"unable to get config")
+	case configList.FullCommand():
+		kingpin.FatalIfError(
+			configListHandler(out),
+			"unable to list profiles")
+	case configUseProfile.FullCommand():
+		kingpin.FatalIfError(
+			configUseProfileHandler(*configUseProfileName),
+			"unable to use profile")
+
+		// Events
 	case eventsStore.FullCommand():
 		kingpin.FatalIfError(
 			eventsStoreHandler(client, *eventsStoreAggType, *eventsStoreAggID, *eventsStoreEventType, *eventsStoreEventID, *eventsStoreData, *eventsStoreExpectedVersion),
 			"unable to store event")
+	case eventsImport.FullCommand():
+		kingpin.FatalIfError(
+			eventsImportHandler(client, *eventsImportFiles, *eventsImportBatchSize, *eventsImportConcurrency, *eventsImportReport, *eventsImportResume),
+			"unable to import events")
+
+		// Completion
+	case completionCmd.FullCommand():
+		kingpin.FatalIfError(
+			completionHandler(*completionShell),
+			"unable to print completion script")
+
+		// Doctor
+	case doctorCmd.FullCommand():
+		kingpin.FatalIfError(
+			doctorHandler(out, *profileName, *baseURL),
+			"unable to run diagnostics")
+
+		// UI
+	case uiCmd.FullCommand():
+		kingpin.FatalIfError(
+			uiHandler(client),
+			"unable to run UI")
+
+		// Migrate
+	case migrate.FullCommand():
+		kingpin.FatalIfError(
+			migrateHandler(*migrateFrom, *migrateTo, *migrateFeeds, *migrateEventTypes, *migrateCheckpoint),
+			"unable to migrate events")
 
 		// Aggregates
 	case aggregatesGet.FullCommand():
 		kingpin.FatalIfError(
-			aggregatesGetHandler(client, *aggregatesGetType, *aggregatesGetID, *aggregatesGetLimit),
+			aggregatesGetHandler(client, out, *aggregatesGetType, *aggregatesGetID, *aggregatesGetLimit),
 			"unable to get aggregate")
+	case aggregatesList.FullCommand():
+		kingpin.FatalIfError(
+			aggregatesListHandler(client, out, *aggregatesListType, *aggregatesListSkip, *aggregatesListLimit, *aggregatesListSort),
+			"unable to list aggregates")
+	case aggregatesFold.FullCommand():
+		kingpin.FatalIfError(
+			aggregatesFoldHandler(client, out, *aggregatesFoldType, *aggregatesFoldID, *aggregatesFoldReducer, *aggregatesFoldAtVersion, *aggregatesFoldSteps),
+			"unable to fold aggregate")
 	case aggregatesDelete.FullCommand():
 		kingpin.FatalIfError(
-			aggregatesDeleteHandler(client, *aggregatesDeleteType),
+			aggregatesDeleteHandler(client, *aggregatesDeleteType, deleteOptions{
+				aggID:  *aggregatesDeleteID,
+				yes:    *aggregatesDeleteYes || *aggregatesDeleteForce,
+				dryRun: *aggregatesDeleteDryRun,
+			}),
 			"unable to delete aggregate")
 
 		// Projections
 	case projectionsSingleGet.FullCommand():
 		kingpin.FatalIfError(
-			projectionsSingleGetHandler(client, *projectionsSingleGetName, *projectionsSingleGetAggregateID),
+			projectionsSingleGetHandler(client, out, *projectionsSingleGetName, *projectionsSingleGetAggregateID),
 			"unable to get single projection")
+	case projectionsSingleList.FullCommand():
+		kingpin.FatalIfError(
+			projectionsSingleListHandler(client, out, *projectionsSingleListName, *projectionsSingleListLimit, *projectionsSingleListSort),
+			"unable to list single projections")
 	case projectionsAggregatedGet.FullCommand():
 		kingpin.FatalIfError(
-			projectionsAggregatedGetHandler(client, *projectionsAggregatedGetName),
+			projectionsAggregatedGetHandler(client, out, *projectionsAggregatedGetName),
 			"unable to get aggregated projection")
 	case projectionsAggregatedList.FullCommand():
 		kingpin.FatalIfError(
-			projectionsAggregatedListHandler(client),
+			projectionsAggregatedListHandler(client, out),
 			"unable to list aggregated projections")
 	case projectionsDefinitionsGet.FullCommand():
 		kingpin.FatalIfError(
-			projectionsDefinitionsGetHandler(client, *projectionsDefinitionsGetName),
+			projectionsDefinitionsGetHandler(client, out, *projectionsDefinitionsGetName),
 			"unable to get projection definition")
 	case projectionsDefinitionsDelete.FullCommand():
 		kingpin.FatalIfError(
@@ -117,23 +300,47 @@ func main() {
 			"unable to delete projection definition")
 	case projectionsDefinitionsList.FullCommand():
 		kingpin.FatalIfError(
-			projectionsDefinitionsListHandler(client),
+			projectionsDefinitionsListHandler(client, out),
 			"unable to list projection definitions")
+	case projectionsDefinitionsCreate.FullCommand():
+		kingpin.FatalIfError(
+			projectionsDefinitionsCreateHandler(client, *projectionsDefinitionsCreateFile, *projectionsDefinitionsCreateReplace, *projectionsDefinitionsCreateFromExisting),
+			"unable to create projection definition")
+	case projectionsDefinitionsRebuild.FullCommand():
+		kingpin.FatalIfError(
+			projectionsDefinitionsRebuildHandler(client, *projectionsDefinitionsRebuildName),
+			"unable to rebuild projection")
 
 		// Feeds
 	case feedsGet.FullCommand():
 		kingpin.FatalIfError(
-			feedsGetHandler(client, *feedsGetName, *feedsGetSince, *feedsGetCurrent),
+			feedsGetHandler(client, out, *feedsGetName, *feedsGetSince, *feedsGetCurrent),
 			"unable to get feed")
+	case feedsTail.FullCommand():
+		kingpin.FatalIfError(
+			feedsTailHandler(client, out, *feedsTailName, tailOptions{
+				eventTypes:  *feedsTailEventTypes,
+				aggregateID: *feedsTailAggregateID,
+				filter:      *feedsTailFilter,
+				showData:    *feedsTailShowData,
+				fromHead:    *feedsTailFromHead,
+				since:       *feedsTailSince,
+				count:       *feedsTailCount,
+			}),
+			"unable to tail feed")
+	case feedsExport.FullCommand():
+		kingpin.FatalIfError(
+			feedsExportHandler(client, out, *feedsExportName, *feedsExportSince, *feedsExportUntil, *feedsExportOut, *feedsExportCompress, int64(*feedsExportChunkSize)),
+			"unable to export feed")
 	case feedsList.FullCommand():
 		kingpin.FatalIfError(
-			feedsListHandler(client),
+			feedsListHandler(client, out),
 			"unable to list feeds")
 
 		// Reactions
 	case reactionsDefinitionsGet.FullCommand():
 		kingpin.FatalIfError(
-			reactionsDefinitionsGetHandler(client, *reactionsDefinitionsGetName),
+			reactionsDefinitionsGetHandler(client, out, *reactionsDefinitionsGetName),
 			"unable to get reaction definition")
 	case reactionsDefinitionsDelete.FullCommand():
 		kingpin.FatalIfError(
@@ -141,7 +348,31 @@ func main() {
 			"unable to delete reaction definition")
 	case reactionsDefinitionsList.FullCommand():
 		kingpin.FatalIfError(
-			reactionsDefinitionsListHandler(client),
+			reactionsDefinitionsListHandler(client, out),
 			"unable to list reaction definitions")
+	case reactionsDefinitionsCreate.FullCommand():
+		kingpin.FatalIfError(
+			reactionsDefinitionsCreateHandler(client, *reactionsDefinitionsCreateFile, *reactionsDefinitionsCreateReplace, *reactionsDefinitionsCreateFromExisting),
+			"unable to create reaction definition")
+	case reactionsScheduledList.FullCommand():
+		kingpin.FatalIfError(
+			reactionsScheduledListHandler(client, out, *reactionsScheduledListName, *reactionsScheduledListAggID, *reactionsScheduledListFrom, *reactionsScheduledListTo, *reactionsScheduledListSkip, *reactionsScheduledListLimit),
+			"unable to list scheduled reactions")
+	case reactionsScheduledCancel.FullCommand():
+		kingpin.FatalIfError(
+			reactionsScheduledCancelHandler(client, *reactionsScheduledCancelID),
+			"unable to cancel scheduled reaction")
+	case reactionsTriggeredList.FullCommand():
+		kingpin.FatalIfError(
+			reactionsTriggeredListHandler(client, out, *reactionsTriggeredListName, *reactionsTriggeredListAggID, *reactionsTriggeredListFrom, *reactionsTriggeredListTo, *reactionsTriggeredListSkip, *reactionsTriggeredListLimit),
+			"unable to list triggered reactions")
+	case reactionsTriggeredReexecute.FullCommand():
+		kingpin.FatalIfError(
+			reactionsTriggeredReexecuteHandler(client, *reactionsTriggeredReexecuteID),
+			"unable to re-execute reaction")
+	case reactionsPreview.FullCommand():
+		kingpin.FatalIfError(
+			reactionsPreviewHandler(client, *reactionsPreviewName, *reactionsPreviewEvent, *reactionsPreviewAggID),
+			"unable to preview reaction")
 	}
 }
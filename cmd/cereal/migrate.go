@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func migrateHandler(fromProfile, toProfile string, feeds, eventTypes []string, checkpointPath string) error {
+	if fromProfile == toProfile {
+		return errors.New("source and target profiles must differ")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Credentials from the environment are ignored, since they can't apply
+	// to both accounts.
+	source, err := cfg.lookupProfile(fromProfile)
+	if err != nil {
+		return err
+	}
+	sourceOpts, err := source.clientOptions()
+	if err != nil {
+		return err
+	}
+
+	target, err := cfg.lookupProfile(toProfile)
+	if err != nil {
+		return err
+	}
+	targetOpts, err := target.clientOptions()
+	if err != nil {
+		return err
+	}
+
+	opts := []func(*serialized.MigrateOptions){
+		serialized.WithMigrateFeeds(feeds...),
+		serialized.WithMigrateEventTypes(eventTypes...),
+	}
+
+	if checkpointPath != "" {
+		store, err := serialized.NewFileMigrateCheckpointStore(checkpointPath)
+		if err != nil {
+			return fmt.Errorf("unable to load checkpoints: %s", err)
+		}
+		opts = append(opts, serialized.WithMigrateCheckpoints(store))
+	}
+
+	bar := newProgressBar(os.Stderr)
+	opts = append(opts, serialized.WithMigrateProgress(func(p serialized.MigrateProgress) {
+		bar.Update(p.Percent(), fmt.Sprintf("%s (%d/%d)", p.Feed, p.Current, p.Target))
+	}))
+
+	res, err := serialized.Migrate(context.Background(),
+		serialized.NewClient(sourceOpts...),
+		serialized.NewClient(targetOpts...),
+		opts...,
+	)
+	bar.Done()
+	if err != nil {
+		if checkpointPath != "" {
+			return fmt.Errorf("%s. Run the command again to resume", err)
+		}
+		return err
+	}
+
+	fmt.Printf("Migrated %d events in %d entries from %q to %q, skipped %d events.\n", res.Events, res.Entries, fromProfile, toProfile, res.Skipped)
+
+	return nil
+}
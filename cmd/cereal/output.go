@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputNDJSON   = "ndjson"
+	outputTemplate = "template"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML, outputNDJSON, outputTemplate + "=<template>"}
+
+// printer writes command results in the format chosen with --output.
+type printer struct {
+	w      io.Writer
+	format string
+	tmpl   *template.Template
+}
+
+// newPrinter returns a printer for the given --output value. Templates are
+// given as template=<template>, e.g. template='{{.ID}}'.
+func newPrinter(w io.Writer, output string) (*printer, error) {
+	p := &printer{w: w, format: output}
+
+	if strings.HasPrefix(output, outputTemplate+"=") {
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, outputTemplate+"="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %s", err)
+		}
+		p.format = outputTemplate
+		p.tmpl = tmpl
+	}
+
+	switch p.format {
+	case outputTable, outputJSON, outputYAML, outputNDJSON, outputTemplate:
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of: %s", output, strings.Join(outputFormats, ", "))
+	}
+
+	if p.format == outputTemplate && p.tmpl == nil {
+		return nil, fmt.Errorf("missing template, use --output=template=<template>")
+	}
+
+	return p, nil
+}
+
+// table reports whether results should be printed as human-readable text.
+func (p *printer) table() bool {
+	return p.format == outputTable
+}
+
+// newTabWriter returns the tabwriter used for table output.
+func (p *printer) newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(p.w, 0, 8, 2, '\t', 0)
+}
+
+// newRowWriter returns a tabwriter for tables that are flushed row by row.
+// Since columns are only aligned within a flush, they're padded to at least
+// minwidth, and line up as long as no cell is wider.
+func (p *printer) newRowWriter(minwidth int) *tabwriter.Writer {
+	return tabwriter.NewWriter(p.w, minwidth, 8, 2, '\t', 0)
+}
+
+// Print writes v in the configured format. In table format, the given
+// function writes the human-readable version of v instead. For ndjson and
+// template formats, slices are printed one element per line.
+func (p *printer) Print(v interface{}, table func(w *tabwriter.Writer) error) error {
+	switch p.format {
+	case outputTable:
+		w := p.newTabWriter()
+		if err := table(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.w, string(b))
+		return err
+	case outputYAML:
+		b, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+	}
+
+	for _, item := range items(v) {
+		if err := p.printItem(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *printer) printItem(v interface{}) error {
+	if p.format == outputTemplate {
+		if err := p.tmpl.Execute(p.w, v); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(p.w)
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, string(b))
+	return err
+}
+
+// items returns the elements of v if it's a slice, or v itself otherwise.
+func items(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+
+	res := make([]interface{}, rv.Len())
+	for i := range res {
+		res[i] = rv.Index(i).Interface()
+	}
+	return res
+}
+
+// toYAML encodes v as YAML. The value is encoded as JSON first, so that the
+// field names match those of the other formats.
+func toYAML(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(generic)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// progressBar renders a single-line progress bar that is redrawn in place.
+type progressBar struct {
+	w     io.Writer
+	width int
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w, width: 40}
+}
+
+// Update redraws the progress bar with the given percentage and label.
+func (p *progressBar) Update(percent float64, label string) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	n := int(percent / 100 * float64(p.width))
+
+	bar := strings.Repeat("=", n)
+	if n < p.width {
+		bar += ">" + strings.Repeat(" ", p.width-n-1)
+	}
+
+	fmt.Fprintf(p.w, "\r[%s] %3.0f%% %s", bar, percent, label)
+}
+
+// Done ends the progress bar line.
+func (p *progressBar) Done() {
+	fmt.Fprintln(p.w)
+}
@@ -5,101 +5,209 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
 	serialized "github.com/marcusolsson/serialized-go"
 )
 
-func projectionsSingleGetHandler(c *serialized.Client, projName, aggID string) error {
+func projectionsSingleGetHandler(c *serialized.Client, out *printer, projName, aggID string) error {
 	proj, err := c.SingleProjection(context.Background(), projName, aggID)
 	if err != nil {
 		return err
 	}
 
+	return out.Print(proj, func(w *tabwriter.Writer) error {
+		return printProjectionData(w, proj)
+	})
+}
+
+func printProjectionData(w io.Writer, proj *serialized.Projection) error {
 	var buf bytes.Buffer
 	if err := json.Indent(&buf, proj.Data, "", "  "); err != nil {
 		return err
 	}
 
-	fmt.Println(buf.String())
-
-	return nil
+	_, err := fmt.Fprintln(w, buf.String())
+	return err
 }
 
-func projectionsAggregatedGetHandler(c *serialized.Client, projName string) error {
-	proj, err := c.AggregatedProjection(context.Background(), projName)
-	if err != nil {
-		return err
+func projectionsSingleListHandler(c *serialized.Client, out *printer, projName string, limit int, sort string) error {
+	var opts []serialized.ProjectionListOption
+
+	if sort != "" {
+		opts = append(opts, serialized.WithSort(strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")))
+	}
+	if limit > 0 {
+		opts = append(opts, serialized.WithLimit(limit))
 	}
 
-	var buf bytes.Buffer
-	if err := json.Indent(&buf, proj.Data, "", "  "); err != nil {
+	it := c.IterateSingleProjections(context.Background(), projName, opts...)
+
+	projs := []*serialized.Projection{}
+	for it.Next() {
+		projs = append(projs, it.Projection())
+	}
+	if err := it.Err(); err != nil {
 		return err
 	}
 
-	fmt.Println(buf.String())
+	if len(projs) == 0 && out.table() {
+		fmt.Println("No single projections found.")
+		return nil
+	}
+
+	return out.Print(projs, func(w *tabwriter.Writer) error {
+		return printProjections(w, projs)
+	})
+}
+
+// printProjections prints a table with a column for each top-level field of
+// the projection data. Nested values are printed as JSON, and data that
+// isn't an object is printed in a DATA column.
+func printProjections(w io.Writer, projs []*serialized.Projection) error {
+	var (
+		rows    = make([]map[string]interface{}, len(projs))
+		other   = make([]string, len(projs))
+		fields  []string
+		seen    = make(map[string]bool)
+		hasData bool
+	)
+
+	for i, p := range projs {
+		var v interface{}
+		if len(p.Data) > 0 {
+			if err := json.Unmarshal(p.Data, &v); err != nil {
+				return err
+			}
+		}
+
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			if v != nil {
+				other[i] = formatField(v)
+				hasData = true
+			}
+			continue
+		}
+
+		rows[i] = m
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	header := []string{"ID"}
+	for _, f := range fields {
+		header = append(header, strings.ToUpper(f))
+	}
+	if hasData {
+		header = append(header, "DATA")
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for i, p := range projs {
+		row := []string{p.ID}
+		for _, f := range fields {
+			if v, ok := rows[i][f]; ok {
+				row = append(row, formatField(v))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if hasData {
+			row = append(row, other[i])
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
 
 	return nil
 }
 
-func projectionsAggregatedListHandler(c *serialized.Client) error {
+// formatField formats a value of projection data for a table cell. Strings
+// are printed as they are, unless they would break the table, and other
+// values as compact JSON.
+func formatField(v interface{}) string {
+	if s, ok := v.(string); ok && !strings.ContainsAny(s, "\t\r\n") {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func projectionsAggregatedGetHandler(c *serialized.Client, out *printer, projName string) error {
+	proj, err := c.AggregatedProjection(context.Background(), projName)
+	if err != nil {
+		return err
+	}
+
+	return out.Print(proj, func(w *tabwriter.Writer) error {
+		return printProjectionData(w, proj)
+	})
+}
+
+func projectionsAggregatedListHandler(c *serialized.Client, out *printer) error {
 	projs, err := c.ListAggregatedProjections(context.Background())
 	if err != nil {
 		return err
 	}
 
-	if len(projs) == 0 {
+	if len(projs) == 0 && out.table() {
 		fmt.Println("No aggregated projections found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
-
-	fmt.Fprintln(w, strings.Join([]string{"ID", "DATA"}, "\t"))
-	for _, p := range projs {
-		fmt.Fprintln(w, strings.Join([]string{p.ID, string(p.Data)}, "\t"))
-	}
-
-	return w.Flush()
+	return out.Print(projs, func(w *tabwriter.Writer) error {
+		return printProjections(w, projs)
+	})
 }
 
-func projectionsDefinitionsGetHandler(c *serialized.Client, name string) error {
+func projectionsDefinitionsGetHandler(c *serialized.Client, out *printer, name string) error {
 	proj, err := c.ProjectionDefinition(context.Background(), name)
 	if err != nil {
 		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	return out.Print(proj, func(w *tabwriter.Writer) error {
+		return printProjectionDefinition(w, proj)
+	})
+}
 
+func printProjectionDefinition(w *tabwriter.Writer, proj *serialized.ProjectionDefinition) error {
 	var level int
 
-	fmt.Fprintf(w, kv("Name", proj.Name, level))
-	fmt.Fprintf(w, kv("Feed", proj.Feed, level))
-	fmt.Fprintf(w, kv("Handlers", "", level))
+	fmt.Fprint(w, kv("Name", proj.Name, level))
+	fmt.Fprint(w, kv("Feed", proj.Feed, level))
+	fmt.Fprint(w, kv("Handlers", "", level))
 
 	w.Flush()
 
 	for i, h := range proj.Handlers {
 		level++
-		fmt.Fprintf(w, kv(h.EventType, "", level))
+		fmt.Fprint(w, kv(h.EventType, "", level))
 
 		w.Flush()
 
 		for _, f := range h.Functions {
 			level++
-			fmt.Fprintf(w, kv("Function", f.Function, level))
-			fmt.Fprintf(w, kv("Target selector", f.TargetSelector, level))
-			fmt.Fprintf(w, kv("Event selector", f.EventSelector, level))
-			fmt.Fprintf(w, kv("Target filter", f.TargetFilter, level))
-			fmt.Fprintf(w, kv("Event filter", f.EventFilter, level))
-			fmt.Fprintf(w, kv("Raw data", fmt.Sprintf("%s", f.RawData), level))
+			fmt.Fprint(w, kv("Function", string(f.Function), level))
+			fmt.Fprint(w, kv("Target selector", f.TargetSelector, level))
+			fmt.Fprint(w, kv("Event selector", f.EventSelector, level))
+			fmt.Fprint(w, kv("Target filter", f.TargetFilter, level))
+			fmt.Fprint(w, kv("Event filter", f.EventFilter, level))
+			fmt.Fprint(w, kv("Raw data", fmt.Sprintf("%s", f.RawData), level))
 
 			w.Flush()
 
 			if i < len(proj.Handlers)-1 {
-				fmt.Println()
+				fmt.Fprintln(w)
 			}
 		}
 	}
@@ -121,23 +229,42 @@ func projectionsDefinitionsDeleteHandler(c *serialized.Client, name string) erro
 	return nil
 }
 
-func projectionsDefinitionsListHandler(c *serialized.Client) error {
+func projectionsDefinitionsListHandler(c *serialized.Client, out *printer) error {
 	defs, err := c.ListProjectionDefinitions(context.Background())
 	if err != nil {
 		return err
 	}
 
-	if len(defs) == 0 {
+	if len(defs) == 0 && out.table() {
 		fmt.Println("No projection definitions found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	return out.Print(defs, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"NAME", "FEED"}, "\t"))
+		for _, d := range defs {
+			fmt.Fprintln(w, strings.Join([]string{d.Name, d.Feed}, "\t"))
+		}
+		return nil
+	})
+}
+
+func projectionsDefinitionsRebuildHandler(c *serialized.Client, name string) error {
+	bar := newProgressBar(os.Stderr)
 
-	fmt.Fprintln(w, strings.Join([]string{"NAME", "FEED"}, "\t"))
-	for _, d := range defs {
-		fmt.Fprintln(w, strings.Join([]string{d.Name, d.Feed}, "\t"))
+	err := c.RebuildProjection(context.Background(), name, func(p serialized.RebuildProgress) {
+		bar.Update(p.Percent(), fmt.Sprintf("(%d/%d)", p.Current, p.Target))
+	})
+	bar.Done()
+	if err == serialized.ErrRebuildProgressUnavailable {
+		fmt.Printf("projection %q recreated, and is being rebuilt in the background\n", name)
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("projection %q rebuilt\n", name)
 
-	return w.Flush()
+	return nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"text/tabwriter"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func TestPrintProjections(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		projs []*serialized.Projection
+		want  string
+	}{
+		{
+			name: "objects",
+			projs: []*serialized.Projection{
+				{ID: "a", Data: json.RawMessage(`{"total":10,"status":"PAID","items":[1,2]}`)},
+				{ID: "b", Data: json.RawMessage(`{"total":5,"note":"two\nlines"}`)},
+			},
+			want: "" +
+				"ID  ITEMS  NOTE          STATUS  TOTAL\n" +
+				"a   [1,2]                PAID    10\n" +
+				"b          \"two\\nlines\"          5\n",
+		},
+		{
+			name: "other values",
+			projs: []*serialized.Projection{
+				{ID: "a", Data: json.RawMessage(`{"total":10}`)},
+				{ID: "b", Data: json.RawMessage(`[1]`)},
+			},
+			want: "" +
+				"ID  TOTAL  DATA\n" +
+				"a   10     \n" +
+				"b          [1]\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+			if err := printProjections(w, tt.projs); err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got =\n%s\nwant =\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,45 +2,92 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http/httputil"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	serialized "github.com/marcusolsson/serialized-go"
 )
 
-func reactionsDefinitionsGetHandler(c *serialized.Client, name string) error {
+func reactionsDefinitionsGetHandler(c *serialized.Client, out *printer, name string) error {
 	def, err := c.ReactionDefinition(context.Background(), name)
 	if err != nil {
 		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	def = redactReactionDefinition(def)
 
+	return out.Print(def, func(w *tabwriter.Writer) error {
+		return printReactionDefinition(w, def)
+	})
+}
+
+func printReactionDefinition(w *tabwriter.Writer, def *serialized.ReactionDefinition) error {
 	var level int
 
-	fmt.Fprintf(w, kv("Name", def.Name, level))
-	fmt.Fprintf(w, kv("Feed", def.Feed, level))
-	fmt.Fprintf(w, kv("Reacts on", def.ReactOnEventType, level))
-	fmt.Fprintf(w, kv("Cancels on", strings.Join(def.CancelOnEventTypes, ", "), level))
-	fmt.Fprintf(w, kv("Trigger time field", def.TriggerTimeField, level))
-	fmt.Fprintf(w, kv("Offset", def.Offset, level))
-	fmt.Fprintf(w, kv("Action", "", level))
+	fmt.Fprint(w, kv("Name", def.Name, level))
+	fmt.Fprint(w, kv("Feed", def.Feed, level))
+	fmt.Fprint(w, kv("Reacts on", def.ReactOnEventType, level))
+	fmt.Fprint(w, kv("Cancels on", strings.Join(def.CancelOnEventTypes, ", "), level))
+	fmt.Fprint(w, kv("Trigger time field", def.TriggerTimeField, level))
+	fmt.Fprint(w, kv("Offset", def.Offset, level))
+	fmt.Fprint(w, kv("Action", "", level))
 
 	w.Flush()
 
 	level++
 
-	fmt.Fprintf(w, kv("Type", string(def.Action.ActionType), level))
-	fmt.Fprintf(w, kv("Target URI", def.Action.TargetURI, level))
-	fmt.Fprintf(w, kv("Body", def.Action.Body, level))
+	fmt.Fprint(w, kv("Type", string(def.Action.ActionType), level))
+	fmt.Fprint(w, kv("Target URI", def.Action.TargetURI, level))
+	fmt.Fprint(w, kv("Body", def.Action.Body, level))
 
-	w.Flush()
+	if def.Action.Config != nil {
+		b, err := json.Marshal(def.Action.Config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, kv("Config", string(b), level))
+	}
 
 	return nil
 }
 
+// redactReactionDefinition returns a copy of def for display, with the
+// signing secret of HTTP_POST actions redacted.
+func redactReactionDefinition(def *serialized.ReactionDefinition) *serialized.ReactionDefinition {
+	if def.Action == nil {
+		return def
+	}
+
+	cfg, ok := def.Action.Config.(*serialized.HTTPConfig)
+	if !ok || cfg.SigningSecret == "" {
+		return def
+	}
+
+	redactedCfg := *cfg
+	redactedCfg.SigningSecret = redact(cfg.SigningSecret)
+
+	action := *def.Action
+	action.Config = &redactedCfg
+
+	redacted := *def
+	redacted.Action = &action
+
+	return &redacted
+}
+
+// redact masks all but the last four characters of a secret.
+func redact(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
+
 func reactionsDefinitionsDeleteHandler(c *serialized.Client, name string) error {
 	if err := c.DeleteReactionDefinition(context.Background(), name); err != nil {
 		return err
@@ -51,23 +98,162 @@ func reactionsDefinitionsDeleteHandler(c *serialized.Client, name string) error
 	return nil
 }
 
-func reactionsDefinitionsListHandler(c *serialized.Client) error {
+func reactionsDefinitionsListHandler(c *serialized.Client, out *printer) error {
 	defs, err := c.ListReactionDefinitions(context.Background())
 	if err != nil {
 		return err
 	}
 
-	if len(defs) == 0 {
+	if len(defs) == 0 && out.table() {
 		fmt.Println("No reactions found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	for i, d := range defs {
+		defs[i] = redactReactionDefinition(d)
+	}
+
+	return out.Print(defs, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"NAME", "FEED", "REACTS ON", "ACTION", "TARGET URI"}, "\t"))
+		for _, d := range defs {
+			fmt.Fprintln(w, strings.Join([]string{d.Name, d.Feed, d.ReactOnEventType, string(d.Action.ActionType), d.Action.TargetURI}, "\t"))
+		}
+		return nil
+	})
+}
+
+func reactionsPreviewHandler(c *serialized.Client, name, eventFile, aggID string) error {
+	def, err := c.ReactionDefinition(context.Background(), name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(eventFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var ev serialized.Event
+	if err := json.NewDecoder(f).Decode(&ev); err != nil {
+		return fmt.Errorf("unable to decode event: %s", err)
+	}
+
+	req, err := serialized.PreviewReaction(def, aggID, &ev)
+	if err != nil {
+		return err
+	}
+
+	b, err := httputil.DumpRequest(req, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
 
-	fmt.Fprintln(w, strings.Join([]string{"NAME", "FEED", "REACTS ON", "ACTION", "TARGET URI"}, "\t"))
-	for _, d := range defs {
-		fmt.Fprintln(w, strings.Join([]string{d.Name, d.Feed, d.ReactOnEventType, string(d.Action.ActionType), d.Action.TargetURI}, "\t"))
+func reactionsScheduledListHandler(c *serialized.Client, out *printer, name, aggID, from, to string, skip, limit int) error {
+	opts, err := reactionListOptions(name, aggID, from, to, skip, limit)
+	if err != nil {
+		return err
 	}
 
-	return w.Flush()
+	page, err := c.ScheduledReactionsPage(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(page.Reactions) == 0 && out.table() {
+		fmt.Println("No scheduled reactions found.")
+		return nil
+	}
+
+	return printReactions(out, page, skip)
+}
+
+func reactionsScheduledCancelHandler(c *serialized.Client, id string) error {
+	if err := c.CancelScheduledReaction(context.Background(), id); err != nil {
+		return err
+	}
+
+	fmt.Printf("scheduled reaction %q cancelled\n", id)
+
+	return nil
+}
+
+func reactionsTriggeredListHandler(c *serialized.Client, out *printer, name, aggID, from, to string, skip, limit int) error {
+	opts, err := reactionListOptions(name, aggID, from, to, skip, limit)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.TriggeredReactionsPage(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(page.Reactions) == 0 && out.table() {
+		fmt.Println("No triggered reactions found.")
+		return nil
+	}
+
+	return printReactions(out, page, skip)
+}
+
+func reactionsTriggeredReexecuteHandler(c *serialized.Client, id string) error {
+	if err := c.ReexecuteReaction(context.Background(), id); err != nil {
+		return err
+	}
+
+	fmt.Printf("reaction %q re-executed\n", id)
+
+	return nil
+}
+
+func reactionListOptions(name, aggID, from, to string, skip, limit int) ([]serialized.ReactionListOption, error) {
+	var fromTime, toTime time.Time
+
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --from: %s", err)
+		}
+		fromTime = t
+	}
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --to: %s", err)
+		}
+		toTime = t
+	}
+
+	return []serialized.ReactionListOption{
+		serialized.WithReactionName(name),
+		serialized.WithAggregateID(aggID),
+		serialized.WithTimeWindow(fromTime, toTime),
+		serialized.WithSkip(skip),
+		serialized.WithLimit(limit),
+	}, nil
+}
+
+func printReactions(out *printer, page *serialized.ReactionPage, skip int) error {
+	err := out.Print(page.Reactions, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, strings.Join([]string{"ID", "NAME", "AGGREGATE ID", "STATUS", "TRIGGER AT"}, "\t"))
+		for _, r := range page.Reactions {
+			fmt.Fprintln(w, strings.Join([]string{r.ID, r.Name, r.AggregateID, string(r.Status), r.TriggerTime().Format(time.RFC1123Z)}, "\t"))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if page.HasMore && out.table() {
+		fmt.Printf("\nMore reactions available. Use --skip=%d to show the next page.\n", skip+len(page.Reactions))
+	}
+
+	return nil
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+	"golang.org/x/term"
+)
+
+// tailOptions holds the flags of the feeds tail command.
+type tailOptions struct {
+	eventTypes  []string
+	aggregateID string
+	filter      string
+	showData    bool
+	fromHead    bool
+	since       int64
+	count       int
+}
+
+// tailEvent is an event printed by feeds tail, along with its feed entry.
+type tailEvent struct {
+	SequenceNumber int64             `json:"sequenceNumber"`
+	AggregateID    string            `json:"aggregateId"`
+	Timestamp      int64             `json:"timestamp"`
+	Event          *serialized.Event `json:"event"`
+}
+
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorCyan   = "\x1b[36m"
+	colorYellow = "\x1b[33m"
+)
+
+func feedsTailHandler(c *serialized.Client, out *printer, feed string, opts tailOptions) error {
+	for _, pattern := range opts.eventTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid event type pattern %q: %s", pattern, err)
+		}
+	}
+
+	var expr filterExpr
+	if opts.filter != "" {
+		var err error
+		if expr, err = parseFilter(opts.filter); err != nil {
+			return fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	since := opts.since
+	if opts.fromHead {
+		seq, err := c.FeedSequenceNumber(ctx, feed)
+		if err != nil {
+			return fmt.Errorf("unable to get sequence number: %s", err)
+		}
+		since = seq
+	}
+
+	color := isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+
+	var (
+		n        int
+		printErr error
+	)
+
+	err := c.Feed(ctx, feed, since, func(e *serialized.FeedEntry) {
+		if opts.aggregateID != "" && e.AggregateID != opts.aggregateID {
+			return
+		}
+
+		for _, ev := range e.Events {
+			if opts.count > 0 && n >= opts.count {
+				return
+			}
+			if !matchEventType(opts.eventTypes, ev.Type) {
+				continue
+			}
+			if expr != nil && !matchFilter(expr, ev.Data) {
+				continue
+			}
+
+			te := &tailEvent{
+				SequenceNumber: e.SequenceNumber,
+				AggregateID:    e.AggregateID,
+				Timestamp:      e.Timestamp,
+				Event:          ev,
+			}
+
+			if out.table() {
+				printTailEvent(os.Stdout, te, opts.showData, color)
+			} else if err := out.Print(te, nil); err != nil {
+				printErr = err
+				cancel()
+				return
+			}
+
+			n++
+			if opts.count > 0 && n >= opts.count {
+				cancel()
+			}
+		}
+	})
+	if printErr != nil {
+		return printErr
+	}
+	if err == context.Canceled {
+		return nil
+	}
+
+	return err
+}
+
+func matchEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func printTailEvent(w io.Writer, e *tailEvent, showData, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	ts := time.Unix(0, e.Timestamp*int64(time.Millisecond)).Format(time.RFC3339)
+
+	line := []string{
+		paint(colorDim, ts),
+		paint(colorDim, fmt.Sprint(e.SequenceNumber)),
+		paint(colorCyan, e.AggregateID),
+		paint(colorYellow, e.Event.Type),
+	}
+
+	if showData && len(e.Event.Data) > 0 {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, e.Event.Data); err != nil {
+			line = append(line, string(e.Event.Data))
+		} else {
+			line = append(line, buf.String())
+		}
+	}
+
+	fmt.Fprintln(w, strings.Join(line, " "))
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
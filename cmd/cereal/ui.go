@@ -0,0 +1,586 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	serialized "github.com/marcusolsson/serialized-go"
+	"github.com/mattn/go-runewidth"
+)
+
+// uiRow is a row in a list view.
+type uiRow struct {
+	cols []string
+
+	// id is copied to the clipboard.
+	id string
+
+	// detail is shown as JSON next to the list.
+	detail interface{}
+
+	// open returns the view to show when the row is selected, and jump
+	// returns the view of the aggregate the row refers to.
+	open func() *uiView
+	jump func() *uiView
+}
+
+// uiView is a list of rows, loaded in the background.
+type uiView struct {
+	title  string
+	header []string
+	rows   []*uiRow
+
+	// load adds rows to the view, until it's done or the context is
+	// cancelled. Rows are added on the UI goroutine.
+	load func(ctx context.Context, add func(rows ...*uiRow)) error
+
+	// maxRows limits the number of rows kept by views that keep loading,
+	// by dropping the oldest ones.
+	maxRows int
+
+	// follow keeps the last row selected as rows are added.
+	follow     bool
+	followable bool
+
+	sel          int
+	offset       int
+	detailOffset int
+	filter       string
+
+	loading bool
+	err     error
+	cancel  context.CancelFunc
+}
+
+// visible returns the rows matching the search filter.
+func (v *uiView) visible() []*uiRow {
+	if v.filter == "" {
+		return v.rows
+	}
+
+	q := strings.ToLower(v.filter)
+
+	var res []*uiRow
+	for _, r := range v.rows {
+		if strings.Contains(strings.ToLower(r.id), q) || strings.Contains(strings.ToLower(strings.Join(r.cols, " ")), q) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// selected returns the selected row, or nil if there are no rows.
+func (v *uiView) selected() *uiRow {
+	rows := v.visible()
+	if v.sel < 0 || v.sel >= len(rows) {
+		return nil
+	}
+	return rows[v.sel]
+}
+
+func (v *uiView) move(n int) {
+	rows := v.visible()
+
+	v.sel += n
+	if v.sel >= len(rows) {
+		v.sel = len(rows) - 1
+	}
+	if v.sel < 0 {
+		v.sel = 0
+	}
+	v.detailOffset = 0
+
+	if v.followable {
+		v.follow = v.sel == len(rows)-1
+	}
+}
+
+// ui is the state of the terminal UI.
+type ui struct {
+	client *serialized.Client
+	screen tcell.Screen
+
+	// sections are the top-level views. The stack holds the views opened
+	// from the current section.
+	sections []func() *uiView
+	section  int
+	stack    []*uiView
+
+	searching bool
+	status    string
+}
+
+func uiHandler(c *serialized.Client) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return errors.New("the UI needs a terminal")
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+
+	return newUI(c, screen).run()
+}
+
+func newUI(c *serialized.Client, screen tcell.Screen) *ui {
+	u := &ui{
+		client: c,
+		screen: screen,
+	}
+	u.sections = []func() *uiView{
+		u.feedsView,
+		u.projectionDefinitionsView,
+		u.reactionDefinitionsView,
+	}
+	u.setSection(0)
+
+	return u
+}
+
+func (u *ui) run() error {
+	for {
+		u.draw()
+
+		switch ev := u.screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			u.screen.Sync()
+		case *tcell.EventInterrupt:
+			if fn, ok := ev.Data().(func()); ok {
+				fn()
+			}
+		case *tcell.EventKey:
+			if quit := u.handleKey(ev); quit {
+				for _, v := range u.stack {
+					v.cancel()
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// current returns the view on top of the stack.
+func (u *ui) current() *uiView {
+	return u.stack[len(u.stack)-1]
+}
+
+func (u *ui) setSection(i int) {
+	for _, v := range u.stack {
+		v.cancel()
+	}
+	u.section = i
+	u.stack = nil
+	u.push(u.sections[i]())
+}
+
+func (u *ui) push(v *uiView) {
+	u.stack = append(u.stack, v)
+	u.start(v)
+}
+
+func (u *ui) pop() {
+	if len(u.stack) == 1 {
+		return
+	}
+	u.current().cancel()
+	u.stack = u.stack[:len(u.stack)-1]
+}
+
+// start loads the rows of a view in the background.
+func (u *ui) start(v *uiView) {
+	if v.cancel != nil {
+		v.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	v.cancel = cancel
+	v.rows = nil
+	v.sel = 0
+	v.offset = 0
+	v.err = nil
+	v.loading = true
+
+	add := func(rows ...*uiRow) {
+		u.post(ctx, func() {
+			v.rows = append(v.rows, rows...)
+			if v.maxRows > 0 && len(v.rows) > v.maxRows {
+				n := len(v.rows) - v.maxRows
+				v.rows = v.rows[n:]
+				if !v.follow {
+					v.move(-n)
+				}
+			}
+			if v.follow {
+				v.sel = len(v.visible()) - 1
+			}
+		})
+	}
+
+	go func() {
+		err := v.load(ctx, add)
+		u.post(ctx, func() {
+			v.loading = false
+			if err != nil && err != context.Canceled {
+				v.err = err
+			}
+		})
+	}()
+}
+
+// post runs fn on the UI goroutine, unless the context has been cancelled.
+func (u *ui) post(ctx context.Context, fn func()) {
+	u.screen.PostEventWait(tcell.NewEventInterrupt(func() {
+		if ctx.Err() == nil {
+			fn()
+		}
+	}))
+}
+
+// handleKey handles a key press, and reports whether to quit.
+func (u *ui) handleKey(ev *tcell.EventKey) bool {
+	v := u.current()
+
+	if u.searching {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			u.searching = false
+		case tcell.KeyEscape:
+			u.searching = false
+			v.filter = ""
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if v.filter != "" {
+				r := []rune(v.filter)
+				v.filter = string(r[:len(r)-1])
+			}
+		case tcell.KeyRune:
+			v.filter += string(ev.Rune())
+		}
+		v.sel = 0
+		v.offset = 0
+		return false
+	}
+
+	u.status = ""
+
+	_, h := u.screen.Size()
+	page := h - 4
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyUp:
+		v.move(-1)
+	case tcell.KeyDown:
+		v.move(1)
+	case tcell.KeyPgUp:
+		v.move(-page)
+	case tcell.KeyPgDn:
+		v.move(page)
+	case tcell.KeyHome:
+		v.move(-len(v.rows))
+	case tcell.KeyEnd:
+		v.move(len(v.rows))
+	case tcell.KeyEnter:
+		if r := v.selected(); r != nil && r.open != nil {
+			u.push(r.open())
+		}
+	case tcell.KeyEscape, tcell.KeyBackspace, tcell.KeyBackspace2:
+		if v.filter != "" {
+			v.filter = ""
+		} else {
+			u.pop()
+		}
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true
+		case 'k':
+			v.move(-1)
+		case 'j':
+			v.move(1)
+		case 'g':
+			v.move(-len(v.rows))
+		case 'G':
+			v.move(len(v.rows))
+		case '[':
+			if v.detailOffset > 0 {
+				v.detailOffset--
+			}
+		case ']':
+			v.detailOffset++
+		case '1', '2', '3':
+			u.setSection(int(ev.Rune() - '1'))
+		case '/':
+			u.searching = true
+		case 'r':
+			u.start(v)
+		case 'f':
+			if v.followable {
+				v.follow = !v.follow
+				if v.follow {
+					v.move(len(v.rows))
+				}
+			}
+		case 'a':
+			if r := v.selected(); r != nil && r.jump != nil {
+				u.push(r.jump())
+			}
+		case 'y':
+			if r := v.selected(); r != nil && r.id != "" {
+				if err := u.copy(r.id); err != nil {
+					u.status = fmt.Sprintf("unable to copy: %s", err)
+				} else {
+					u.status = fmt.Sprintf("copied %s", r.id)
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// copy copies text to the clipboard. The escape sequence used when there's
+// no clipboard command is written to the terminal of the screen, and the
+// screen is redrawn in case the terminal printed any of it.
+func (u *ui) copy(text string) error {
+	var term io.Writer = os.Stdout
+	if tty, ok := u.screen.Tty(); ok {
+		term = tty
+	}
+
+	err := copyToClipboard(text, term)
+	u.screen.Sync()
+
+	return err
+}
+
+var (
+	uiStyle         = tcell.StyleDefault
+	uiBarStyle      = tcell.StyleDefault.Reverse(true)
+	uiHeaderStyle   = tcell.StyleDefault.Bold(true)
+	uiSelectedStyle = tcell.StyleDefault.Reverse(true)
+	uiDimStyle      = tcell.StyleDefault.Dim(true)
+	uiErrorStyle    = tcell.StyleDefault.Foreground(tcell.ColorRed)
+)
+
+func (u *ui) draw() {
+	s := u.screen
+	s.Clear()
+
+	w, h := s.Size()
+	v := u.current()
+
+	// Title bar, with the sections and the path to the current view.
+	fill(s, 0, 0, w, uiBarStyle)
+	x := 0
+	for i, name := range []string{"Feeds", "Projections", "Reactions"} {
+		style := uiBarStyle
+		if i == u.section {
+			style = style.Bold(true).Underline(true)
+		}
+		x += drawText(s, x, 0, w-x, fmt.Sprintf(" %d:%s ", i+1, name), style)
+	}
+
+	var titles []string
+	for _, v := range u.stack {
+		titles = append(titles, v.title)
+	}
+	drawText(s, x, 0, w-x, " | "+strings.Join(titles, " > "), uiBarStyle)
+
+	// The list takes up the left part of the screen, and the details of
+	// the selected row the right part.
+	listWidth := w
+	if w >= 80 {
+		listWidth = w * 55 / 100
+	}
+
+	u.drawList(v, 0, 1, listWidth, h-2)
+
+	if listWidth < w {
+		for y := 1; y < h-1; y++ {
+			s.SetContent(listWidth, y, tcell.RuneVLine, nil, uiDimStyle)
+		}
+		u.drawDetail(v, listWidth+2, 1, w-listWidth-2, h-2)
+	}
+
+	// Status bar, with the state of the view to the right.
+	var info []string
+	if v.filter != "" && !u.searching {
+		info = append(info, "filter: "+v.filter)
+	}
+	if v.follow {
+		info = append(info, "FOLLOW")
+	}
+	if v.loading {
+		// Views that can be followed keep loading until they're closed.
+		if v.followable {
+			info = append(info, "live")
+		} else {
+			info = append(info, "loading...")
+		}
+	}
+
+	var infoText string
+	if len(info) > 0 {
+		infoText = " " + strings.Join(info, " | ") + " "
+	}
+	infoWidth := runewidth.StringWidth(infoText)
+
+	fill(s, 0, h-1, w, uiBarStyle)
+	switch {
+	case u.searching:
+		drawText(s, 0, h-1, w-infoWidth, "/"+v.filter, uiBarStyle)
+		s.ShowCursor(1+runewidth.StringWidth(v.filter), h-1)
+	case u.status != "":
+		drawText(s, 0, h-1, w-infoWidth, " "+u.status, uiBarStyle)
+		s.HideCursor()
+	default:
+		help := " q:quit  enter:open  esc:back  /:search  a:aggregate  y:copy id  r:refresh  [ ]:scroll details"
+		if v.followable {
+			help += "  f:follow"
+		}
+		drawText(s, 0, h-1, w-infoWidth, help, uiBarStyle)
+		s.HideCursor()
+	}
+	drawText(s, w-infoWidth, h-1, infoWidth, infoText, uiBarStyle)
+
+	s.Show()
+}
+
+func (u *ui) drawList(v *uiView, x, y, w, h int) {
+	s := u.screen
+
+	if v.err != nil {
+		drawText(s, x+1, y, w-1, v.err.Error(), uiErrorStyle)
+		return
+	}
+
+	rows := v.visible()
+	if len(rows) == 0 {
+		if !v.loading {
+			drawText(s, x+1, y, w-1, "Nothing to show.", uiDimStyle)
+		}
+		return
+	}
+
+	widths := columnWidths(v.header, rows, w-1)
+
+	drawColumns(s, x+1, y, widths, v.header, uiHeaderStyle)
+
+	// Keep the selected row on screen.
+	height := h - 1
+	if v.sel < v.offset {
+		v.offset = v.sel
+	}
+	if v.sel >= v.offset+height {
+		v.offset = v.sel - height + 1
+	}
+
+	for i := 0; i < height && v.offset+i < len(rows); i++ {
+		style := uiStyle
+		if v.offset+i == v.sel {
+			style = uiSelectedStyle
+			fill(s, x, y+1+i, w, style)
+		}
+		drawColumns(s, x+1, y+1+i, widths, rows[v.offset+i].cols, style)
+	}
+}
+
+func (u *ui) drawDetail(v *uiView, x, y, w, h int) {
+	r := v.selected()
+	if r == nil || r.detail == nil {
+		return
+	}
+
+	b, err := json.MarshalIndent(r.detail, "", "  ")
+	if err != nil {
+		drawText(u.screen, x, y, w, err.Error(), uiErrorStyle)
+		return
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if v.detailOffset > len(lines)-1 {
+		v.detailOffset = len(lines) - 1
+	}
+
+	for i := 0; i < h && v.detailOffset+i < len(lines); i++ {
+		drawText(u.screen, x, y+i, w, lines[v.detailOffset+i], uiStyle)
+	}
+}
+
+// columnWidths fits the columns into the given width. Every column but the
+// last is as wide as its widest value, and the last one gets what's left.
+func columnWidths(header []string, rows []*uiRow, width int) []int {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = runewidth.StringWidth(h)
+	}
+	for _, r := range rows {
+		for i, c := range r.cols {
+			if i < len(widths) {
+				if n := runewidth.StringWidth(c); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+
+	left := width
+	for i := range widths {
+		if i == len(widths)-1 || widths[i]+2 > left {
+			widths[i] = left
+			return widths[:i+1]
+		}
+		widths[i] += 2
+		left -= widths[i]
+	}
+
+	return widths
+}
+
+func drawColumns(s tcell.Screen, x, y int, widths []int, cols []string, style tcell.Style) {
+	for i, w := range widths {
+		if i < len(cols) {
+			drawText(s, x, y, w, cols[i], style)
+		}
+		x += w
+	}
+}
+
+// drawText draws text, truncated to the given width, and returns the width
+// of the drawn text.
+func drawText(s tcell.Screen, x, y, width int, text string, style tcell.Style) int {
+	if width <= 0 {
+		return 0
+	}
+
+	text = runewidth.Truncate(text, width, "…")
+
+	n := 0
+	for _, r := range text {
+		s.SetContent(x+n, y, r, nil, style)
+		n += runewidth.RuneWidth(r)
+	}
+
+	return n
+}
+
+func fill(s tcell.Screen, x, y, width int, style tcell.Style) {
+	for i := 0; i < width; i++ {
+		s.SetContent(x+i, y, ' ', nil, style)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+func TestUIViewFilter(t *testing.T) {
+	v := &uiView{rows: []*uiRow{
+		{id: "a1", cols: []string{"OrderPlaced"}},
+		{id: "b2", cols: []string{"OrderPaid"}},
+		{id: "c3", cols: []string{"PaymentProcessed"}},
+	}}
+
+	v.move(2)
+	if got := v.selected().id; got != "c3" {
+		t.Fatalf("selected = %s; want = %s", got, "c3")
+	}
+
+	v.filter = "order"
+	if got := len(v.visible()); got != 2 {
+		t.Fatalf("visible rows = %d; want = %d", got, 2)
+	}
+
+	v.move(-5)
+	if got := v.selected().id; got != "a1" {
+		t.Fatalf("selected = %s; want = %s", got, "a1")
+	}
+
+	v.filter = "B2"
+	if got := v.visible(); len(got) != 1 || got[0].id != "b2" {
+		t.Fatalf("unexpected visible rows = %v", got)
+	}
+}
+
+func TestUIViewFollow(t *testing.T) {
+	v := &uiView{followable: true, rows: []*uiRow{{id: "1"}, {id: "2"}, {id: "3"}}}
+
+	v.move(1)
+	if v.follow {
+		t.Fatalf("following with a row other than the last selected")
+	}
+
+	v.move(1)
+	if !v.follow {
+		t.Fatalf("not following with the last row selected")
+	}
+}
+
+func TestColumnWidths(t *testing.T) {
+	rows := []*uiRow{
+		{cols: []string{"abcdef", "x", "long value"}},
+		{cols: []string{"åäö", "xyz"}},
+	}
+
+	for _, tt := range []struct {
+		width int
+		want  []int
+	}{
+		{width: 80, want: []int{8, 6, 66}},
+		{width: 10, want: []int{8, 2}},
+		{width: 5, want: []int{5}},
+	} {
+		if got := columnWidths([]string{"ID", "TYPE", "DATA"}, rows, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("columnWidths(%d) = %v; want = %v", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestUIAggregateView(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aggregates/order/a" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"aggregateId":"a","aggregateType":"order","aggregateVersion":5,"events":[{"eventId":"4","eventType":"OrderPaid"},{"eventId":"5","eventType":"OrderShipped"}]}`))
+	}))
+	defer ts.Close()
+
+	u := &ui{client: serialized.NewClient(serialized.WithBaseURL(ts.URL))}
+
+	v := u.aggregateView("order", "a")
+
+	var rows []*uiRow
+	err := v.load(context.Background(), func(r ...*uiRow) {
+		rows = append(rows, r...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, r := range rows {
+		got = append(got, r.cols)
+	}
+
+	want := [][]string{
+		{"4", "OrderPaid", "4"},
+		{"5", "OrderShipped", "5"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %v; want = %v", got, want)
+	}
+}
+
+func TestCopyToClipboardOSC52(t *testing.T) {
+	defer func(cmds [][]string) { clipboardCommands = cmds }(clipboardCommands)
+
+	for name, cmds := range map[string][][]string{
+		"missing": {{"cereal-test-missing-clipboard"}},
+		"failing": {{"false"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			clipboardCommands = cmds
+
+			var buf bytes.Buffer
+			if err := copyToClipboard("2c3cf88c", &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := buf.String(), "\x1b]52;c;MmMzY2Y4OGM=\x07"; got != want {
+				t.Fatalf("got = %q; want = %q", got, want)
+			}
+		})
+	}
+}
+
+func TestUIReactionDefinitionsViewCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	u := &ui{client: serialized.NewClient(serialized.WithBaseURL(ts.URL))}
+
+	// Views are cancelled when they're refreshed or left while loading.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := u.reactionDefinitionsView().load(ctx, func(...*uiRow) {
+		t.Error("unexpected rows")
+	})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error = %v; want = %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	serialized "github.com/marcusolsson/serialized-go"
+)
+
+// uiFeedEntries is the number of entries loaded before the head of a feed
+// when it's opened.
+const uiFeedEntries = 500
+
+// uiMaxRows is the number of rows kept by views that keep loading.
+const uiMaxRows = 5000
+
+// uiListLimit is the number of items requested by list views.
+const uiListLimit = 500
+
+func (u *ui) feedsView() *uiView {
+	return &uiView{
+		title:  "Feeds",
+		header: []string{"AGGREGATE TYPE", "AGGREGATES", "EVENTS"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			feeds, err := u.client.Feeds(ctx)
+			if err != nil {
+				return err
+			}
+
+			var rows []*uiRow
+			for _, f := range feeds {
+				f := f
+				rows = append(rows, &uiRow{
+					cols:   []string{f.AggregateType, fmt.Sprint(f.AggregateCount), fmt.Sprint(f.EventCount)},
+					id:     f.AggregateType,
+					detail: f,
+					open:   func() *uiView { return u.feedView(f.AggregateType) },
+					jump:   func() *uiView { return u.aggregatesView(f.AggregateType) },
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+// feedView shows the latest entries of a feed, and keeps adding new entries
+// as they're stored.
+func (u *ui) feedView(feed string) *uiView {
+	return &uiView{
+		title:      feed,
+		header:     []string{"SEQ", "TIME", "AGGREGATE ID", "EVENTS"},
+		maxRows:    uiMaxRows,
+		follow:     true,
+		followable: true,
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			head, err := u.client.FeedSequenceNumber(ctx, feed)
+			if err != nil {
+				return err
+			}
+
+			since := head - uiFeedEntries
+			if since < 0 {
+				since = 0
+			}
+
+			return u.client.Feed(ctx, feed, since, func(e *serialized.FeedEntry) {
+				var types []string
+				for _, ev := range e.Events {
+					types = append(types, ev.Type)
+				}
+
+				add(&uiRow{
+					cols: []string{
+						fmt.Sprint(e.SequenceNumber),
+						formatMillis(e.Timestamp),
+						e.AggregateID,
+						strings.Join(types, ", "),
+					},
+					id:     e.AggregateID,
+					detail: e,
+					open:   func() *uiView { return u.aggregateView(feed, e.AggregateID) },
+					jump:   func() *uiView { return u.aggregateView(feed, e.AggregateID) },
+				})
+			})
+		},
+	}
+}
+
+func (u *ui) aggregatesView(aggType string) *uiView {
+	return &uiView{
+		title:  aggType + " aggregates",
+		header: []string{"AGGREGATE ID", "VERSION", "LAST UPDATED"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			page, err := u.client.ListAggregates(ctx, aggType,
+				serialized.WithLimit(uiListLimit),
+				serialized.WithSort("lastUpdated", true),
+			)
+			if err != nil {
+				return err
+			}
+
+			var rows []*uiRow
+			for _, a := range page.Aggregates {
+				a := a
+				rows = append(rows, &uiRow{
+					cols:   []string{a.ID, fmt.Sprint(a.Version), formatMillis(a.LastUpdated)},
+					id:     a.ID,
+					detail: a,
+					open:   func() *uiView { return u.aggregateView(aggType, a.ID) },
+					jump:   func() *uiView { return u.aggregateView(aggType, a.ID) },
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+// aggregateView shows the events of an aggregate.
+func (u *ui) aggregateView(aggType, aggID string) *uiView {
+	return &uiView{
+		title:  aggType + " " + aggID,
+		header: []string{"VERSION", "EVENT TYPE", "EVENT ID"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			agg, err := u.client.LoadAggregate(ctx, aggType, aggID)
+			if err != nil {
+				return err
+			}
+
+			first := firstVersion(agg)
+
+			var rows []*uiRow
+			for i, ev := range agg.Events {
+				rows = append(rows, &uiRow{
+					cols:   []string{fmt.Sprint(first + int64(i)), ev.Type, ev.ID},
+					id:     ev.ID,
+					detail: ev,
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+func (u *ui) projectionDefinitionsView() *uiView {
+	return &uiView{
+		title:  "Projections",
+		header: []string{"NAME", "FEED"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			defs, err := u.client.ListProjectionDefinitions(ctx)
+			if err != nil {
+				return err
+			}
+
+			var rows []*uiRow
+			for _, d := range defs {
+				d := d
+				rows = append(rows, &uiRow{
+					cols:   []string{d.Name, d.Feed},
+					id:     d.Name,
+					detail: d,
+					open:   func() *uiView { return u.singleProjectionsView(d) },
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+// singleProjectionsView shows the single projections of a definition. The
+// projections are keyed by aggregate ID, so they can jump to the aggregate
+// in the feed of the definition.
+func (u *ui) singleProjectionsView(def *serialized.ProjectionDefinition) *uiView {
+	return &uiView{
+		title:  def.Name,
+		header: []string{"PROJECTION ID", "DATA"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			projs, err := u.client.ListSingleProjections(ctx, def.Name, serialized.WithLimit(uiListLimit))
+			if err != nil {
+				return err
+			}
+
+			var rows []*uiRow
+			for _, p := range projs {
+				p := p
+				rows = append(rows, &uiRow{
+					cols:   []string{p.ID, compactJSON(p.Data)},
+					id:     p.ID,
+					detail: p,
+					jump:   func() *uiView { return u.aggregateView(def.Feed, p.ID) },
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+func (u *ui) reactionDefinitionsView() *uiView {
+	return &uiView{
+		title:  "Reactions",
+		header: []string{"NAME", "FEED", "REACTS ON"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			defs, err := u.client.ListReactionDefinitions(ctx)
+			if err != nil {
+				return err
+			}
+
+			var rows []*uiRow
+			for _, d := range defs {
+				d := d
+				rows = append(rows, &uiRow{
+					cols:   []string{d.Name, d.Feed, d.ReactOnEventType},
+					id:     d.Name,
+					detail: redactReactionDefinition(d),
+					open:   func() *uiView { return u.reactionsView(d) },
+				})
+			}
+			add(rows...)
+
+			return nil
+		},
+	}
+}
+
+// reactionsView shows the scheduled and triggered reactions of a
+// definition.
+func (u *ui) reactionsView(def *serialized.ReactionDefinition) *uiView {
+	return &uiView{
+		title:  def.Name,
+		header: []string{"STATUS", "TRIGGER AT", "AGGREGATE ID", "REACTION ID"},
+		load: func(ctx context.Context, add func(...*uiRow)) error {
+			pages := []func(context.Context, ...serialized.ReactionListOption) (*serialized.ReactionPage, error){
+				u.client.ScheduledReactionsPage,
+				u.client.TriggeredReactionsPage,
+			}
+
+			for _, fn := range pages {
+				page, err := fn(ctx, serialized.WithReactionName(def.Name), serialized.WithLimit(uiListLimit))
+				if err != nil {
+					return err
+				}
+
+				var rows []*uiRow
+				for _, r := range page.Reactions {
+					r := r
+					rows = append(rows, &uiRow{
+						cols:   []string{string(r.Status), r.TriggerTime().Format(time.RFC3339), r.AggregateID, r.ID},
+						id:     r.ID,
+						detail: r,
+						jump:   func() *uiView { return u.aggregateView(def.Feed, r.AggregateID) },
+					})
+				}
+				add(rows...)
+			}
+
+			return nil
+		},
+	}
+}
+
+func formatMillis(ms int64) string {
+	return time.Unix(0, ms*int64(time.Millisecond)).Format(time.RFC3339)
+}
+
+func compactJSON(b json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
@@ -0,0 +1,211 @@
+package serialized
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is an ISO-8601 duration, such as PT1H or P1DT12H, as used for
+// reaction offsets. As in ISO-8601, the smallest unit may have a fraction,
+// such as P1.5D or PT0.5H.
+type Duration struct {
+	Negative bool
+
+	Years  float64
+	Months float64
+	Weeks  float64
+	Days   float64
+
+	Hours   float64
+	Minutes float64
+	Seconds float64
+}
+
+const oneDay = 24 * time.Hour
+
+// ParseDuration parses an ISO-8601 duration.
+func ParseDuration(s string) (Duration, error) {
+	var d Duration
+
+	orig := s
+
+	if strings.HasPrefix(s, "-") {
+		d.Negative, s = true, s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) < 2 || strings.HasSuffix(s, "T") {
+		return Duration{}, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var (
+		inTime bool
+		num    string
+
+		// units are the designators in the order they must appear, and
+		// next is the index of the first one still allowed, so that
+		// repeated and out-of-order units are rejected.
+		units = "YMWD"
+		next  int
+
+		// fraction is set once a value with a fraction has been parsed,
+		// since only the smallest unit may have one.
+		fraction bool
+	)
+
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9' || r == '.' || r == ',':
+			num += string(r)
+			continue
+		case r == 'T':
+			if inTime || num != "" || fraction {
+				return Duration{}, fmt.Errorf("invalid duration %q", orig)
+			}
+			inTime = true
+			units, next = "HMS", 0
+			continue
+		}
+
+		if num == "" || fraction {
+			return Duration{}, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		if !strings.ContainsRune(units, r) {
+			return Duration{}, fmt.Errorf("invalid duration %q", orig)
+		}
+		i := strings.IndexRune(units[next:], r)
+		if i < 0 {
+			return Duration{}, fmt.Errorf("invalid duration %q: unit %c repeated or out of order", orig, r)
+		}
+		next += i + 1
+
+		num = strings.Replace(num, ",", ".", 1)
+
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return Duration{}, fmt.Errorf("invalid duration %q", orig)
+		}
+		fraction = strings.Contains(num, ".")
+		num = ""
+
+		switch {
+		case !inTime && r == 'Y':
+			d.Years = n
+		case !inTime && r == 'M':
+			d.Months = n
+		case !inTime && r == 'W':
+			d.Weeks = n
+		case !inTime && r == 'D':
+			d.Days = n
+		case inTime && r == 'H':
+			d.Hours = n
+		case inTime && r == 'M':
+			d.Minutes = n
+		case inTime && r == 'S':
+			d.Seconds = n
+		default:
+			return Duration{}, fmt.Errorf("invalid duration %q", orig)
+		}
+	}
+
+	if num != "" {
+		return Duration{}, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	return d, nil
+}
+
+// DurationOf returns the Duration for d, expressed in days, hours, minutes
+// and seconds.
+func DurationOf(d time.Duration) Duration {
+	var res Duration
+
+	if d < 0 {
+		res.Negative, d = true, -d
+	}
+
+	days := d / oneDay
+	d -= days * oneDay
+
+	hours := d / time.Hour
+	d -= hours * time.Hour
+
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+
+	res.Days, res.Hours, res.Minutes = float64(days), float64(hours), float64(minutes)
+
+	res.Seconds = d.Seconds()
+
+	return res
+}
+
+// Duration returns d as a time.Duration. Years and months are approximated
+// as 365 and 30 days respectively.
+func (d Duration) Duration() time.Duration {
+	res := time.Duration(d.Years*float64(365*oneDay)) +
+		time.Duration(d.Months*float64(30*oneDay)) +
+		time.Duration(d.Weeks*float64(7*oneDay)) +
+		time.Duration(d.Days*float64(oneDay)) +
+		time.Duration(d.Hours*float64(time.Hour)) +
+		time.Duration(d.Minutes*float64(time.Minute)) +
+		time.Duration(d.Seconds*float64(time.Second))
+
+	if d.Negative {
+		res = -res
+	}
+
+	return res
+}
+
+// String returns d formatted as an ISO-8601 duration.
+func (d Duration) String() string {
+	var b strings.Builder
+
+	if d.Negative {
+		b.WriteString("-")
+	}
+	b.WriteString("P")
+
+	write := func(n float64, unit string) {
+		if n != 0 {
+			b.WriteString(strconv.FormatFloat(n, 'f', -1, 64) + unit)
+		}
+	}
+
+	write(d.Years, "Y")
+	write(d.Months, "M")
+	write(d.Weeks, "W")
+	write(d.Days, "D")
+
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 {
+		b.WriteString("T")
+		write(d.Hours, "H")
+		write(d.Minutes, "M")
+		write(d.Seconds, "S")
+	}
+
+	if b.Len() == len("P") || (d.Negative && b.Len() == len("-P")) {
+		return "PT0S"
+	}
+
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
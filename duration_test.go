@@ -0,0 +1,83 @@
+package serialized
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT1H", want: time.Hour},
+		{in: "P1DT12H", want: 36 * time.Hour},
+		{in: "PT1M30.5S", want: 90*time.Second + 500*time.Millisecond},
+		{in: "-P1W", want: -7 * 24 * time.Hour},
+		{in: "P1Y2M", want: (365 + 60) * 24 * time.Hour},
+		{in: "P", wantErr: true},
+		{in: "PT", wantErr: true},
+		{in: "1H", wantErr: true},
+		{in: "P1H", wantErr: true},
+		{in: "PT1", wantErr: true},
+		{in: "PT0.5H", want: 30 * time.Minute},
+		{in: "P1.5D", want: 36 * time.Hour},
+		{in: "P1DT1.5M", want: 24*time.Hour + 90*time.Second},
+		{in: "P1.5DT1H", wantErr: true},
+		{in: "PT1.5H30M", wantErr: true},
+		{in: "PT1.5", wantErr: true},
+		{in: "P1DT", wantErr: true},
+		{in: "PT1H2H", wantErr: true},
+		{in: "PT30M1H", wantErr: true},
+		{in: "P1D2Y", wantErr: true},
+		{in: "P1M1M", wantErr: true},
+		{in: "PT1S2M", wantErr: true},
+		{in: "P1YT1M", want: 365*24*time.Hour + time.Minute},
+	} {
+		d, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q): err = %v; wantErr = %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got := d.Duration(); got != tt.want {
+			t.Errorf("ParseDuration(%q) = %s; want = %s", tt.in, got, tt.want)
+		}
+		if !tt.wantErr && d.String() != tt.in {
+			t.Errorf("ParseDuration(%q).String() = %s; want = %s", tt.in, d.String(), tt.in)
+		}
+	}
+}
+
+func TestDurationOf(t *testing.T) {
+	for _, tt := range []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "PT0S"},
+		{in: time.Hour, want: "PT1H"},
+		{in: 36*time.Hour + 90*time.Second, want: "P1DT12H1M30S"},
+		{in: -15 * time.Minute, want: "-PT15M"},
+	} {
+		if got := DurationOf(tt.in).String(); got != tt.want {
+			t.Errorf("DurationOf(%s) = %s; want = %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	var v struct {
+		Offset Duration `json:"offset"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"offset":"P1DT2H"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := 26 * time.Hour; v.Offset.Duration() != want {
+		t.Fatalf("unexpected duration = %s; want = %s", v.Offset.Duration(), want)
+	}
+	if got, want := string(mustMarshal(v)), `{"offset":"P1DT2H"}`; got != want {
+		t.Fatalf("got = %s; want = %s", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // ErrAggregateNotFound is returned when no events exist for an aggregate ID.
@@ -95,6 +96,61 @@ func (c *Client) DeleteAggregateByType(ctx context.Context, aggType, token strin
 	return nil
 }
 
+// RequestDeleteAggregate requests the deletion of a single aggregate. To
+// delete the aggregate, pass the token returned by this method to
+// DeleteAggregate.
+func (c *Client) RequestDeleteAggregate(ctx context.Context, aggType, aggID string) (string, error) {
+	req, err := c.newRequest("DELETE", "/aggregates/"+aggType+"/"+aggID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		DeleteToken string `json:"deleteToken"`
+	}
+
+	resp, err := c.do(ctx, req, &response)
+
+	// The body of a missing aggregate may not be JSON, so the status code is
+	// checked before the error.
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", ErrAggregateNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return response.DeleteToken, nil
+}
+
+// DeleteAggregate permanently deletes a single aggregate, including all of
+// its events. It requires a token returned from RequestDeleteAggregate.
+func (c *Client) DeleteAggregate(ctx context.Context, aggType, aggID, token string) error {
+	path := fmt.Sprintf("/aggregates/%s/%s?deleteToken=%s", aggType, aggID, url.QueryEscape(token))
+
+	req, err := c.newRequest("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrAggregateNotFound
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // AggregateExists reports whether a specific aggregate exists.
 func (c *Client) AggregateExists(ctx context.Context, aggType, aggID string) (bool, error) {
 	req, err := c.newRequest("HEAD", "/aggregates/"+aggType+"/"+aggID, nil)
@@ -111,3 +111,65 @@ func TestLoadAggregate(t *testing.T) {
 		t.Errorf("unexpected number of events = %d; want = %d", len(agg.Events), 1)
 	}
 }
+
+func TestDeleteAggregate(t *testing.T) {
+	var (
+		aggType = "payment"
+		aggID   = "22c3780f-6dcb-440f-8532-6693be83f21c"
+		token   = "a8b2c3d4"
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Fatalf("unexpected method = %s; want = %s", r.Method, "DELETE")
+		}
+		if r.URL.Path != "/aggregates/"+aggType+"/"+aggID {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		if got := r.URL.Query().Get("deleteToken"); got != "" {
+			if got != token {
+				t.Fatalf("unexpected token = %s; want = %s", got, token)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if _, err := w.Write([]byte(`{"deleteToken":"` + token + `"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	got, err := c.RequestDeleteAggregate(context.Background(), aggType, aggID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != token {
+		t.Fatalf("got = %s; want = %s", got, token)
+	}
+
+	if err := c.DeleteAggregate(context.Background(), aggType, aggID, got); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestDeleteAggregateNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<html>Not Found</html>"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	_, err := c.RequestDeleteAggregate(context.Background(), "payment", "foo")
+	if err != ErrAggregateNotFound {
+		t.Fatalf("unexpected error = %v; want = %v", err, ErrAggregateNotFound)
+	}
+}
@@ -17,10 +17,10 @@ type Feed struct {
 
 // FeedEntry represents an entry in a feed.
 type FeedEntry struct {
-	SequenceNumber int64
-	AggregateID    string
-	Timestamp      int64
-	Events         []*Event
+	SequenceNumber int64    `json:"sequenceNumber"`
+	AggregateID    string   `json:"aggregateId"`
+	Timestamp      int64    `json:"timestamp"`
+	Events         []*Event `json:"events"`
 }
 
 // FeedInfo holds additional information for a feed.
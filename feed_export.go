@@ -0,0 +1,352 @@
+package serialized
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression used for exported files.
+type Compression string
+
+// Supported compressions.
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// Ext returns the file extension for the compression.
+func (c Compression) Ext() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// ExportOptions configures a feed export.
+type ExportOptions struct {
+	// Since exports entries after this sequence number.
+	Since int64
+
+	// Until exports entries up to and including this sequence number. If
+	// zero, entries are exported up to the head of the feed at the time
+	// the export starts.
+	Until int64
+
+	Compression Compression
+
+	// MaxChunkSize is the max number of uncompressed bytes written to each
+	// file. If zero, all entries are written to a single file.
+	MaxChunkSize int64
+}
+
+// WithExportRange sets the range of sequence numbers to export.
+func WithExportRange(since, until int64) func(*ExportOptions) {
+	return func(o *ExportOptions) {
+		o.Since = since
+		o.Until = until
+	}
+}
+
+// WithExportCompression sets the compression of the exported files.
+func WithExportCompression(c Compression) func(*ExportOptions) {
+	return func(o *ExportOptions) {
+		o.Compression = c
+	}
+}
+
+// WithExportChunkSize splits the export into files of at most n
+// uncompressed bytes each.
+func WithExportChunkSize(n int64) func(*ExportOptions) {
+	return func(o *ExportOptions) {
+		o.MaxChunkSize = n
+	}
+}
+
+// ExportManifest describes the files written by an export.
+type ExportManifest struct {
+	Feed        string         `json:"feed"`
+	Since       int64          `json:"since"`
+	Until       int64          `json:"until"`
+	Entries     int            `json:"entries"`
+	Compression Compression    `json:"compression,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Chunks      []*ExportChunk `json:"chunks"`
+}
+
+// ExportChunk describes one exported file.
+type ExportChunk struct {
+	File                string `json:"file"`
+	FirstSequenceNumber int64  `json:"firstSequenceNumber"`
+	LastSequenceNumber  int64  `json:"lastSequenceNumber"`
+	Entries             int    `json:"entries"`
+	Size                int64  `json:"size"`
+	SHA256              string `json:"sha256"`
+}
+
+// ExportFeed writes the entries of a feed, along with their event data, as
+// NDJSON to the file at path. If the export is split into chunks, the chunks
+// are numbered, e.g. payment.00001.ndjson. A manifest describing the
+// exported files is written next to them, and returned.
+//
+// Chunks are written to temporary files, which are renamed once the whole
+// export has succeeded. If the export fails, no files are left behind.
+func (c *Client) ExportFeed(ctx context.Context, name, path string, opts ...func(*ExportOptions)) (*ExportManifest, error) {
+	var o ExportOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	switch o.Compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", o.Compression)
+	}
+
+	until := o.Until
+	if until == 0 {
+		head, err := c.FeedSequenceNumber(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		until = head
+	}
+
+	x := &exporter{
+		path: path,
+		opts: o,
+		manifest: &ExportManifest{
+			Feed:        name,
+			Since:       o.Since,
+			Until:       until,
+			Compression: o.Compression,
+			CreatedAt:   time.Now().UTC(),
+			Chunks:      []*ExportChunk{},
+		},
+	}
+	defer x.abort()
+
+	seq := o.Since
+	for done := seq >= until; !done; {
+		f, err := c.feed(ctx, name, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range f.Entries {
+			if e.SequenceNumber > until {
+				done = true
+				break
+			}
+			if err := x.write(e); err != nil {
+				return nil, err
+			}
+			seq = e.SequenceNumber
+		}
+
+		if !f.HasMore || len(f.Entries) == 0 || seq >= until {
+			done = true
+		}
+	}
+
+	if err := x.close(); err != nil {
+		return nil, err
+	}
+
+	if err := x.commit(); err != nil {
+		return nil, err
+	}
+
+	return x.manifest, nil
+}
+
+// exportBase returns path without its NDJSON and compression extensions.
+func exportBase(path string) string {
+	for _, ext := range []string{CompressionGzip.Ext(), CompressionZstd.Ext(), ".ndjson", ".jsonl"} {
+		path = strings.TrimSuffix(path, ext)
+	}
+	return path
+}
+
+type exporter struct {
+	path     string
+	opts     ExportOptions
+	manifest *ExportManifest
+
+	// paths holds the final path of each chunk. Until the export is
+	// committed, chunks are written to the path with a .tmp suffix.
+	paths []string
+
+	// The chunk currently being written.
+	chunk   *ExportChunk
+	file    *os.File
+	hash    hash.Hash
+	counter *countingWriter
+	w       io.WriteCloser
+	written int64
+}
+
+func (x *exporter) write(e *FeedEntry) error {
+	if x.chunk != nil && x.opts.MaxChunkSize > 0 && x.written >= x.opts.MaxChunkSize {
+		if err := x.close(); err != nil {
+			return err
+		}
+	}
+
+	if x.chunk == nil {
+		if err := x.open(e.SequenceNumber); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	if _, err := x.w.Write(b); err != nil {
+		return err
+	}
+
+	x.written += int64(len(b))
+	x.chunk.LastSequenceNumber = e.SequenceNumber
+	x.chunk.Entries++
+	x.manifest.Entries++
+
+	return nil
+}
+
+func (x *exporter) open(seq int64) error {
+	path := x.path
+	if x.opts.MaxChunkSize > 0 {
+		path = fmt.Sprintf("%s.%05d.ndjson%s", exportBase(x.path), len(x.manifest.Chunks)+1, x.opts.Compression.Ext())
+	}
+
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	x.paths = append(x.paths, path)
+	x.file = f
+	x.hash = sha256.New()
+	x.counter = &countingWriter{w: io.MultiWriter(f, x.hash)}
+	x.written = 0
+
+	switch x.opts.Compression {
+	case CompressionGzip:
+		x.w = gzip.NewWriter(x.counter)
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(x.counter)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		x.w = zw
+	default:
+		x.w = nopWriteCloser{x.counter}
+	}
+
+	x.chunk = &ExportChunk{
+		File:                filepath.Base(path),
+		FirstSequenceNumber: seq,
+	}
+
+	return nil
+}
+
+// close finishes the current chunk and adds it to the manifest.
+func (x *exporter) close() error {
+	if x.chunk == nil {
+		return nil
+	}
+
+	if err := x.w.Close(); err != nil {
+		return err
+	}
+	if err := x.file.Close(); err != nil {
+		return err
+	}
+
+	x.chunk.Size = x.counter.n
+	x.chunk.SHA256 = hex.EncodeToString(x.hash.Sum(nil))
+	x.manifest.Chunks = append(x.manifest.Chunks, x.chunk)
+	x.chunk = nil
+
+	return nil
+}
+
+// commit renames the chunks to their final paths, and writes the manifest.
+func (x *exporter) commit() error {
+	for i, path := range x.paths {
+		if err := os.Rename(path+".tmp", path); err != nil {
+			x.remove(x.paths[:i])
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(x.manifest, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(exportBase(x.path)+".manifest.json", b, 0644)
+	}
+	if err != nil {
+		x.remove(x.paths)
+		return err
+	}
+
+	x.paths = nil
+
+	return nil
+}
+
+// abort closes the file of an unfinished chunk, and removes the temporary
+// files of an export that wasn't committed.
+func (x *exporter) abort() {
+	if x.chunk != nil {
+		x.file.Close()
+	}
+	for _, path := range x.paths {
+		os.Remove(path + ".tmp")
+	}
+}
+
+// remove removes chunks that have been renamed to their final paths.
+func (x *exporter) remove(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,154 @@
+package serialized
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestExportFeed(t *testing.T) {
+	const head = 10
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Serialized-Sequencenumber-Current", strconv.Itoa(head))
+			return
+		}
+
+		since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+		// Return at most three entries per page.
+		var f Feed
+		for seq := since + 1; seq <= head+2 && len(f.Entries) < 3; seq++ {
+			f.Entries = append(f.Entries, &FeedEntry{
+				SequenceNumber: seq,
+				AggregateID:    fmt.Sprintf("agg-%d", seq),
+				Events:         []*Event{{ID: fmt.Sprint(seq), Type: "PaymentProcessed", Data: json.RawMessage(`{"amount":1000}`)}},
+			})
+		}
+		f.HasMore = since+int64(len(f.Entries)) < head+2
+
+		if err := json.NewEncoder(w).Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(WithBaseURL(ts.URL))
+
+	dir := t.TempDir()
+
+	m, err := c.ExportFeed(context.Background(), "payment", filepath.Join(dir, "payment.ndjson.gz"),
+		WithExportRange(2, 0),
+		WithExportCompression(CompressionGzip),
+		WithExportChunkSize(300),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Entries != 8 {
+		t.Fatalf("unexpected number of entries = %d; want = %d", m.Entries, 8)
+	}
+	if len(m.Chunks) < 2 {
+		t.Fatalf("unexpected number of chunks = %d; want at least %d", len(m.Chunks), 2)
+	}
+	if m.Chunks[0].File != "payment.00001.ndjson.gz" {
+		t.Fatalf("unexpected file = %s; want = %s", m.Chunks[0].File, "payment.00001.ndjson.gz")
+	}
+
+	var want int64 = 3
+	for _, chunk := range m.Chunks {
+		b, err := ioutil.ReadFile(filepath.Join(dir, chunk.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != chunk.SHA256 {
+			t.Fatalf("unexpected checksum = %s; want = %s", got, chunk.SHA256)
+		}
+
+		f, err := os.Open(filepath.Join(dir, chunk.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := bufio.NewScanner(zr)
+		for s.Scan() {
+			var e FeedEntry
+			if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.SequenceNumber != want {
+				t.Fatalf("unexpected sequence number = %d; want = %d", e.SequenceNumber, want)
+			}
+			want++
+		}
+	}
+
+	if want != head+1 {
+		t.Fatalf("export ended at = %d; want = %d", want-1, head)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "payment.manifest.json")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportFeedFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Serialized-Sequencenumber-Current", "10")
+			return
+		}
+
+		since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+		if since > 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		f := Feed{HasMore: true}
+		for seq := int64(1); seq <= 5; seq++ {
+			f.Entries = append(f.Entries, &FeedEntry{SequenceNumber: seq, AggregateID: fmt.Sprintf("agg-%d", seq)})
+		}
+
+		if err := json.NewEncoder(w).Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(WithBaseURL(ts.URL))
+
+	dir := t.TempDir()
+
+	_, err := c.ExportFeed(context.Background(), "payment", filepath.Join(dir, "payment.ndjson"), WithExportChunkSize(100))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		t.Errorf("unexpected file = %s", f.Name())
+	}
+}
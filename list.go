@@ -0,0 +1,208 @@
+package serialized
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListOptions holds the paging and sorting options shared by all list
+// operations.
+type ListOptions struct {
+	Skip       int
+	Limit      int
+	SortBy     string
+	Descending bool
+}
+
+// ListOption sets a paging or sorting option. It can be given to any list
+// operation.
+type ListOption func(*ListOptions)
+
+// WithSkip skips the first n items.
+func WithSkip(n int) ListOption {
+	return func(o *ListOptions) {
+		o.Skip = n
+	}
+}
+
+// WithLimit limits the number of items returned.
+func WithLimit(n int) ListOption {
+	return func(o *ListOptions) {
+		o.Limit = n
+	}
+}
+
+// WithSort sorts the items by the given field.
+func WithSort(field string, descending bool) ListOption {
+	return func(o *ListOptions) {
+		o.SortBy = field
+		o.Descending = descending
+	}
+}
+
+func newListOptions(opts ...ListOption) ListOptions {
+	var o ListOptions
+	for _, f := range opts {
+		f(&o)
+	}
+	return o
+}
+
+func (o ListOptions) values() url.Values {
+	vs := make(url.Values)
+
+	if o.Skip > 0 {
+		vs.Set("skip", strconv.Itoa(o.Skip))
+	}
+	if o.Limit > 0 {
+		vs.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.SortBy != "" {
+		if o.Descending {
+			vs.Set("sort", "-"+o.SortBy)
+		} else {
+			vs.Set("sort", o.SortBy)
+		}
+	}
+
+	return vs
+}
+
+// ProjectionListOptions holds the options for listing single projections.
+type ProjectionListOptions struct {
+	ListOptions
+
+	PageSize  int
+	Reference string
+}
+
+// ProjectionListOption sets an option for listing single projections. Any
+// ListOption is also a ProjectionListOption.
+type ProjectionListOption interface {
+	applyProjection(*ProjectionListOptions)
+}
+
+func (f ListOption) applyProjection(o *ProjectionListOptions) {
+	f(&o.ListOptions)
+}
+
+type projectionListOption func(*ProjectionListOptions)
+
+func (f projectionListOption) applyProjection(o *ProjectionListOptions) {
+	f(o)
+}
+
+// WithPageSize sets the number of projections requested per page by
+// iterators. Servers may return smaller pages.
+func WithPageSize(n int) ProjectionListOption {
+	return projectionListOption(func(o *ProjectionListOptions) {
+		o.PageSize = n
+	})
+}
+
+// WithReference only returns projections matching the given reference.
+func WithReference(ref string) ProjectionListOption {
+	return projectionListOption(func(o *ProjectionListOptions) {
+		o.Reference = ref
+	})
+}
+
+func newProjectionListOptions(opts ...ProjectionListOption) ProjectionListOptions {
+	var o ProjectionListOptions
+	for _, opt := range opts {
+		opt.applyProjection(&o)
+	}
+	return o
+}
+
+func (o ProjectionListOptions) values() url.Values {
+	vs := o.ListOptions.values()
+
+	if o.Reference != "" {
+		vs.Set("reference", o.Reference)
+	}
+
+	return vs
+}
+
+// ReactionListOptions holds the options for listing scheduled and triggered
+// reactions.
+type ReactionListOptions struct {
+	ListOptions
+
+	ReactionName string
+	AggregateID  string
+	From         time.Time
+	To           time.Time
+}
+
+// ReactionListOption sets an option for listing reactions. Any ListOption
+// is also a ReactionListOption.
+type ReactionListOption interface {
+	applyReaction(*ReactionListOptions)
+}
+
+func (f ListOption) applyReaction(o *ReactionListOptions) {
+	f(&o.ListOptions)
+}
+
+type reactionListOption func(*ReactionListOptions)
+
+func (f reactionListOption) applyReaction(o *ReactionListOptions) {
+	f(o)
+}
+
+// WithReactionName only returns reactions with the given name.
+func WithReactionName(name string) ReactionListOption {
+	return reactionListOption(func(o *ReactionListOptions) {
+		o.ReactionName = name
+	})
+}
+
+// WithAggregateID only returns reactions for the given aggregate.
+func WithAggregateID(id string) ReactionListOption {
+	return reactionListOption(func(o *ReactionListOptions) {
+		o.AggregateID = id
+	})
+}
+
+// WithTimeWindow only returns reactions within the given time window. A
+// zero time leaves that end of the window open.
+func WithTimeWindow(from, to time.Time) ReactionListOption {
+	return reactionListOption(func(o *ReactionListOptions) {
+		o.From = from
+		o.To = to
+	})
+}
+
+func newReactionListOptions(opts ...ReactionListOption) ReactionListOptions {
+	var o ReactionListOptions
+	for _, opt := range opts {
+		opt.applyReaction(&o)
+	}
+	return o
+}
+
+func (o ReactionListOptions) values() url.Values {
+	vs := o.ListOptions.values()
+
+	if o.ReactionName != "" {
+		vs.Set("reactionName", o.ReactionName)
+	}
+	if o.AggregateID != "" {
+		vs.Set("aggregateId", o.AggregateID)
+	}
+	if !o.From.IsZero() {
+		vs.Set("from", strconv.FormatInt(toMillis(o.From), 10))
+	}
+	if !o.To.IsZero() {
+		vs.Set("to", strconv.FormatInt(toMillis(o.To), 10))
+	}
+
+	return vs
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,286 @@
+package serialized
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// TransformFunc transforms an event before it's stored by a migration.
+// Returning a nil event drops it.
+type TransformFunc func(aggType, aggID string, ev *Event) (*Event, error)
+
+// MigrateOptions configures a migration.
+type MigrateOptions struct {
+	// Feeds to migrate. Since every aggregate type has a feed of its own,
+	// this also limits the aggregate types to migrate. If empty, all feeds
+	// are migrated.
+	Feeds []string
+
+	// EventTypes to migrate. If empty, all events are migrated.
+	EventTypes []string
+
+	Transforms []TransformFunc
+
+	// Checkpoints keeps track of the last migrated entry of each feed, so
+	// that a migration can be resumed.
+	Checkpoints MigrateCheckpointStore
+
+	// Progress is called after each migrated feed entry.
+	Progress func(MigrateProgress)
+}
+
+// MigrateProgress reports the progress of a migration.
+type MigrateProgress struct {
+	Feed    string
+	Current int64
+	Target  int64
+}
+
+// Percent returns the progress of the feed as a percentage.
+func (p MigrateProgress) Percent() float64 {
+	if p.Target <= 0 {
+		return 100
+	}
+	return float64(p.Current) / float64(p.Target) * 100
+}
+
+// MigrateResult summarizes a migration.
+type MigrateResult struct {
+	Entries int
+	Events  int
+
+	// Skipped is the number of events that were dropped by filters or
+	// transforms, or that already existed in the target.
+	Skipped int
+}
+
+// WithMigrateFeeds limits the migration to the given feeds.
+func WithMigrateFeeds(feeds ...string) func(*MigrateOptions) {
+	return func(o *MigrateOptions) {
+		o.Feeds = append(o.Feeds, feeds...)
+	}
+}
+
+// WithMigrateEventTypes limits the migration to the given event types.
+func WithMigrateEventTypes(types ...string) func(*MigrateOptions) {
+	return func(o *MigrateOptions) {
+		o.EventTypes = append(o.EventTypes, types...)
+	}
+}
+
+// WithMigrateTransform adds a transform that is applied to every event,
+// in the order they were added.
+func WithMigrateTransform(fn TransformFunc) func(*MigrateOptions) {
+	return func(o *MigrateOptions) {
+		o.Transforms = append(o.Transforms, fn)
+	}
+}
+
+// WithMigrateCheckpoints sets the store used to resume migrations.
+func WithMigrateCheckpoints(s MigrateCheckpointStore) func(*MigrateOptions) {
+	return func(o *MigrateOptions) {
+		o.Checkpoints = s
+	}
+}
+
+// WithMigrateProgress sets a function to call as the migration progresses.
+func WithMigrateProgress(fn func(MigrateProgress)) func(*MigrateOptions) {
+	return func(o *MigrateOptions) {
+		o.Progress = fn
+	}
+}
+
+// Migrate copies events from the feeds of the source to the target, up to
+// the head of each feed at the time it's migrated. Event IDs are preserved,
+// and events are stored in the order they were stored in the source, using
+// the expected version of each aggregate in the target.
+//
+// With a checkpoint store, the migration resumes after the last migrated
+// entry of each feed. The source and target are identified in checkpoints by
+// their access key and base URL. Events that already exist in the target aggregates
+// are skipped, so an entry that was stored right before the migration was
+// interrupted isn't stored twice.
+func Migrate(ctx context.Context, source, target *Client, opts ...func(*MigrateOptions)) (*MigrateResult, error) {
+	o := MigrateOptions{
+		Checkpoints: NewMemoryMigrateCheckpointStore(),
+	}
+	for _, f := range opts {
+		f(&o)
+	}
+
+	feeds := o.Feeds
+	if len(feeds) == 0 {
+		infos, err := source.Feeds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range infos {
+			feeds = append(feeds, fi.AggregateType)
+		}
+	}
+
+	m := &migration{
+		source: source,
+		target: target,
+		opts:   o,
+		result: &MigrateResult{},
+	}
+
+	for _, feed := range feeds {
+		if err := m.migrateFeed(ctx, feed); err != nil {
+			return m.result, fmt.Errorf("feed %q: %s", feed, err)
+		}
+	}
+
+	return m.result, nil
+}
+
+type migration struct {
+	source *Client
+	target *Client
+	opts   MigrateOptions
+	result *MigrateResult
+}
+
+// aggregateState is the state of an aggregate in the target.
+type aggregateState struct {
+	version int64
+
+	// existing holds the IDs of the events the aggregate had in the target
+	// before the migration started.
+	existing map[string]bool
+}
+
+func (m *migration) migrateFeed(ctx context.Context, feed string) error {
+	seq, err := m.opts.Checkpoints.Checkpoint(m.source.account(), m.target.account(), feed)
+	if err != nil {
+		return err
+	}
+
+	head, err := m.source.FeedSequenceNumber(ctx, feed)
+	if err != nil {
+		return err
+	}
+
+	aggs := make(map[string]*aggregateState)
+
+	for seq < head {
+		f, err := m.source.feed(ctx, feed, seq)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range f.Entries {
+			if e.SequenceNumber > head {
+				return nil
+			}
+
+			agg, ok := aggs[e.AggregateID]
+			if !ok {
+				if agg, err = m.loadTarget(ctx, feed, e.AggregateID); err != nil {
+					return err
+				}
+				aggs[e.AggregateID] = agg
+			}
+
+			if err := m.migrateEntry(ctx, feed, e, agg); err != nil {
+				return fmt.Errorf("entry %d: %s", e.SequenceNumber, err)
+			}
+
+			if err := m.opts.Checkpoints.SetCheckpoint(m.source.account(), m.target.account(), feed, e.SequenceNumber); err != nil {
+				return err
+			}
+			seq = e.SequenceNumber
+
+			if m.opts.Progress != nil {
+				m.opts.Progress(MigrateProgress{Feed: feed, Current: seq, Target: head})
+			}
+		}
+
+		if !f.HasMore || len(f.Entries) == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// account identifies the account of c in migration checkpoints. The access
+// key is hashed, since checkpoints are written to disk.
+func (c *Client) account() string {
+	sum := sha256.Sum256([]byte(c.accessKey + "@" + c.baseURL.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *migration) loadTarget(ctx context.Context, aggType, aggID string) (*aggregateState, error) {
+	exists, err := m.target.AggregateExists(ctx, aggType, aggID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return &aggregateState{}, nil
+	}
+
+	agg, err := m.target.LoadAggregate(ctx, aggType, aggID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &aggregateState{
+		version:  agg.Version,
+		existing: make(map[string]bool),
+	}
+	for _, ev := range agg.Events {
+		state.existing[ev.ID] = true
+	}
+
+	return state, nil
+}
+
+func (m *migration) migrateEntry(ctx context.Context, aggType string, e *FeedEntry, agg *aggregateState) error {
+	var events []*Event
+
+	for _, ev := range e.Events {
+		if agg.existing[ev.ID] {
+			m.result.Skipped++
+			continue
+		}
+		if len(m.opts.EventTypes) > 0 && !containsString(m.opts.EventTypes, ev.Type) {
+			m.result.Skipped++
+			continue
+		}
+
+		for _, fn := range m.opts.Transforms {
+			var err error
+			if ev, err = fn(aggType, e.AggregateID, ev); err != nil {
+				return err
+			}
+			if ev == nil {
+				break
+			}
+		}
+		if ev == nil {
+			m.result.Skipped++
+			continue
+		}
+
+		events = append(events, ev)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := m.target.Store(ctx, aggType, e.AggregateID, agg.version, events...); err != nil {
+		return err
+	}
+
+	agg.version += int64(len(events))
+
+	m.result.Entries++
+	m.result.Events += len(events)
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package serialized
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// MigrateCheckpointStore keeps track of how far feeds have been migrated.
+// Checkpoints are kept per source and target account, so that a store can't
+// resume a migration between other accounts. Accounts are identified by a
+// hash of their access key and base URL.
+type MigrateCheckpointStore interface {
+	// Checkpoint returns the sequence number of the last migrated entry of
+	// a feed.
+	Checkpoint(source, target, feed string) (int64, error)
+
+	// SetCheckpoint sets the sequence number of the last migrated entry of
+	// a feed.
+	SetCheckpoint(source, target, feed string, seq int64) error
+}
+
+// MigrateCheckpoint is the checkpoint of a feed migrated from a source to a
+// target account.
+type MigrateCheckpoint struct {
+	Source         string `json:"source"`
+	Target         string `json:"target"`
+	Feed           string `json:"feed"`
+	SequenceNumber int64  `json:"sequenceNumber"`
+}
+
+type migrateCheckpointKey struct {
+	source, target, feed string
+}
+
+// migrateCheckpoints are the checkpoints held by the built-in stores.
+type migrateCheckpoints map[migrateCheckpointKey]int64
+
+func (m migrateCheckpoints) list() []MigrateCheckpoint {
+	res := make([]MigrateCheckpoint, 0, len(m))
+	for k, seq := range m {
+		res = append(res, MigrateCheckpoint{
+			Source:         k.source,
+			Target:         k.target,
+			Feed:           k.feed,
+			SequenceNumber: seq,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Feed < b.Feed
+	})
+
+	return res
+}
+
+// MemoryMigrateCheckpointStore is a MigrateCheckpointStore that keeps its
+// checkpoints in memory.
+type MemoryMigrateCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints migrateCheckpoints
+}
+
+// NewMemoryMigrateCheckpointStore returns a new MemoryMigrateCheckpointStore.
+func NewMemoryMigrateCheckpointStore() *MemoryMigrateCheckpointStore {
+	return &MemoryMigrateCheckpointStore{checkpoints: make(migrateCheckpoints)}
+}
+
+// Checkpoint returns the sequence number of the last migrated entry.
+func (s *MemoryMigrateCheckpointStore) Checkpoint(source, target, feed string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoints[migrateCheckpointKey{source, target, feed}], nil
+}
+
+// SetCheckpoint sets the sequence number of the last migrated entry.
+func (s *MemoryMigrateCheckpointStore) SetCheckpoint(source, target, feed string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[migrateCheckpointKey{source, target, feed}] = seq
+	return nil
+}
+
+// FileMigrateCheckpointStore is a MigrateCheckpointStore that keeps its
+// checkpoints in a JSON file. Every change is written to the file before the
+// call returns.
+type FileMigrateCheckpointStore struct {
+	path string
+
+	mu          sync.Mutex
+	checkpoints migrateCheckpoints
+}
+
+// NewFileMigrateCheckpointStore returns a FileMigrateCheckpointStore for the
+// file at path. If the file exists, the checkpoints are loaded from it.
+func NewFileMigrateCheckpointStore(path string) (*FileMigrateCheckpointStore, error) {
+	s := &FileMigrateCheckpointStore{
+		path:        path,
+		checkpoints: make(migrateCheckpoints),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state struct {
+		Checkpoints []MigrateCheckpoint `json:"checkpoints"`
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	for _, c := range state.Checkpoints {
+		s.checkpoints[migrateCheckpointKey{c.Source, c.Target, c.Feed}] = c.SequenceNumber
+	}
+
+	return s, nil
+}
+
+// Checkpoint returns the sequence number of the last migrated entry.
+func (s *FileMigrateCheckpointStore) Checkpoint(source, target, feed string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoints[migrateCheckpointKey{source, target, feed}], nil
+}
+
+// SetCheckpoint sets the sequence number of the last migrated entry.
+func (s *FileMigrateCheckpointStore) SetCheckpoint(source, target, feed string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[migrateCheckpointKey{source, target, feed}] = seq
+
+	return writeJSONFile(s.path, struct {
+		Checkpoints []MigrateCheckpoint `json:"checkpoints"`
+	}{s.checkpoints.list()})
+}
@@ -0,0 +1,48 @@
+package serialized
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateCheckpointStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+
+	file, err := NewFileMigrateCheckpointStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]MigrateCheckpointStore{
+		"memory": NewMemoryMigrateCheckpointStore(),
+		"file":   file,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := store.SetCheckpoint("prod", "staging", "order", 42); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tt := range []struct {
+				source, target, feed string
+				want                 int64
+			}{
+				{"prod", "staging", "order", 42},
+				{"prod", "test", "order", 0},
+				{"test", "staging", "order", 0},
+				{"prod", "staging", "payment", 0},
+			} {
+				if seq, err := store.Checkpoint(tt.source, tt.target, tt.feed); err != nil || seq != tt.want {
+					t.Errorf("Checkpoint(%q, %q, %q) = %d, %v; want = %d", tt.source, tt.target, tt.feed, seq, err, tt.want)
+				}
+			}
+		})
+	}
+
+	reloaded, err := NewFileMigrateCheckpointStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, err := reloaded.Checkpoint("prod", "staging", "order"); err != nil || seq != 42 {
+		t.Fatalf("got = %d, %v; want = %d", seq, err, 42)
+	}
+}
@@ -0,0 +1,181 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type storedBatch struct {
+	AggregateID     string   `json:"aggregateId"`
+	Events          []*Event `json:"events"`
+	ExpectedVersion int64    `json:"expectedVersion"`
+}
+
+func newMigrateTestServers(t *testing.T, entries []*FeedEntry, existing *Aggregate) (source, target *Client, stored func() []storedBatch) {
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Serialized-Sequencenumber-Current", strconv.Itoa(len(entries)))
+			return
+		}
+
+		since, _ := strconv.Atoi(r.URL.Query().Get("since"))
+		if err := json.NewEncoder(w).Encode(Feed{Entries: entries[since:]}); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	t.Cleanup(src.Close)
+
+	var (
+		mu      sync.Mutex
+		batches []storedBatch
+	)
+
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			var b storedBatch
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			batches = append(batches, b)
+			mu.Unlock()
+		case "HEAD", "GET":
+			if existing == nil || !strings.HasSuffix(r.URL.Path, "/"+existing.ID) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == "GET" {
+				if err := json.NewEncoder(w).Encode(existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}))
+	t.Cleanup(dst.Close)
+
+	return NewClient(WithBaseURL(src.URL)), NewClient(WithBaseURL(dst.URL)), func() []storedBatch {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	entries := []*FeedEntry{
+		{SequenceNumber: 1, AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}, {ID: "2", Type: "OrderNoted"}}},
+		{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "3", Type: "OrderPlaced"}}},
+		{SequenceNumber: 3, AggregateID: "a", Events: []*Event{{ID: "4", Type: "OrderPaid", Data: json.RawMessage(`{"amount":1}`)}}},
+		{SequenceNumber: 4, AggregateID: "b", Events: []*Event{{ID: "5", Type: "OrderPaid", Data: json.RawMessage(`{"amount":2}`)}}},
+	}
+
+	// Aggregate b was partially migrated before.
+	existing := &Aggregate{ID: "b", Type: "order", Version: 1, Events: []*Event{{ID: "3", Type: "OrderPlaced"}}}
+
+	source, target, stored := newMigrateTestServers(t, entries, existing)
+
+	res, err := Migrate(context.Background(), source, target,
+		WithMigrateFeeds("order"),
+		WithMigrateEventTypes("OrderPlaced", "OrderPaid"),
+		WithMigrateTransform(func(aggType, aggID string, ev *Event) (*Event, error) {
+			if ev.Type == "OrderPaid" {
+				ev.Type = "PaymentReceived"
+			}
+			return ev, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []storedBatch{
+		{AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		{AggregateID: "a", Events: []*Event{{ID: "4", Type: "PaymentReceived", Data: json.RawMessage(`{"amount":1}`)}}, ExpectedVersion: 1},
+		{AggregateID: "b", Events: []*Event{{ID: "5", Type: "PaymentReceived", Data: json.RawMessage(`{"amount":2}`)}}, ExpectedVersion: 1},
+	}
+
+	if got := stored(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got = %s; want = %s", mustMarshal(got), mustMarshal(want))
+	}
+
+	if want := (&MigrateResult{Entries: 3, Events: 3, Skipped: 2}); !reflect.DeepEqual(res, want) {
+		t.Fatalf("got = %+v; want = %+v", res, want)
+	}
+}
+
+func TestMigrateResume(t *testing.T) {
+	entries := []*FeedEntry{
+		{SequenceNumber: 1, AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}},
+		{SequenceNumber: 3, AggregateID: "c", Events: []*Event{{ID: "3", Type: "OrderPlaced"}}},
+	}
+
+	source, target, stored := newMigrateTestServers(t, entries, nil)
+
+	checkpoints := NewMemoryMigrateCheckpointStore()
+	if err := checkpoints.SetCheckpoint(source.account(), target.account(), "order", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(context.Background(), source, target, WithMigrateFeeds("order"), WithMigrateCheckpoints(checkpoints)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stored()
+	if len(got) != 1 || got[0].AggregateID != "c" {
+		t.Fatalf("got = %s; want only aggregate c", mustMarshal(got))
+	}
+
+	if seq, _ := checkpoints.Checkpoint(source.account(), target.account(), "order"); seq != 3 {
+		t.Fatalf("got checkpoint = %d; want = %d", seq, 3)
+	}
+}
+
+func TestMigrateResumeOtherTarget(t *testing.T) {
+	entries := []*FeedEntry{
+		{SequenceNumber: 1, AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}},
+	}
+
+	source, target, stored := newMigrateTestServers(t, entries, nil)
+
+	// The checkpoint is for a migration to another account, so it's not
+	// used.
+	checkpoints := NewMemoryMigrateCheckpointStore()
+	other := NewClient(WithAccessKey("other"))
+	if err := checkpoints.SetCheckpoint(source.account(), other.account(), "order", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(context.Background(), source, target, WithMigrateFeeds("order"), WithMigrateCheckpoints(checkpoints)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stored(); len(got) != 2 {
+		t.Fatalf("got = %s; want both aggregates", mustMarshal(got))
+	}
+}
+
+func TestClientAccount(t *testing.T) {
+	c := NewClient(WithAccessKey("s3cr3t"), WithBaseURL("https://api.example.com"))
+
+	if a := c.account(); strings.Contains(a, "s3cr3t") {
+		t.Fatalf("account %q contains the access key", a)
+	}
+	if c.account() != NewClient(WithAccessKey("s3cr3t"), WithBaseURL("https://api.example.com")).account() {
+		t.Error("same account has different identifiers")
+	}
+	if c.account() == NewClient(WithAccessKey("other"), WithBaseURL("https://api.example.com")).account() {
+		t.Error("accounts with different access keys have the same identifier")
+	}
+	if c.account() == NewClient(WithAccessKey("s3cr3t")).account() {
+		t.Error("accounts with different base URLs have the same identifier")
+	}
+}
@@ -0,0 +1,123 @@
+package serialized
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is a JSON Patch (RFC 6902) operation.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// DiffJSON returns the JSON Patch operations needed to turn the JSON
+// document a into b. An empty document means that the document doesn't
+// exist. Objects are compared field by field, and arrays element by element.
+func DiffJSON(a, b json.RawMessage) ([]PatchOperation, error) {
+	var va, vb interface{}
+
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &va); err != nil {
+			return nil, err
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &vb); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return nil, nil
+	case len(a) == 0:
+		return []PatchOperation{patchOp("add", "", vb)}, nil
+	case len(b) == 0:
+		return []PatchOperation{{Op: "remove", Path: ""}}, nil
+	}
+
+	var ops []PatchOperation
+	diffValue(&ops, "", va, vb)
+	return ops, nil
+}
+
+func diffValue(ops *[]PatchOperation, path string, a, b interface{}) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffObject(ops, path, a, b)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffArray(ops, path, a, b)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, patchOp("replace", path, b))
+	}
+}
+
+func diffObject(ops *[]PatchOperation, path string, a, b map[string]interface{}) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+
+		va, inA := a[k]
+		vb, inB := b[k]
+
+		switch {
+		case inA && inB:
+			diffValue(ops, p, va, vb)
+		case inA:
+			*ops = append(*ops, PatchOperation{Op: "remove", Path: p})
+		default:
+			*ops = append(*ops, patchOp("add", p, vb))
+		}
+	}
+}
+
+func diffArray(ops *[]PatchOperation, path string, a, b []interface{}) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+		diffValue(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+
+	// Remove from the end so that the remaining indices stay valid.
+	for i := len(a) - 1; i >= n; i-- {
+		*ops = append(*ops, PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := n; i < len(b); i++ {
+		*ops = append(*ops, patchOp("add", path+"/"+strconv.Itoa(i), b[i]))
+	}
+}
+
+func patchOp(op, path string, v interface{}) PatchOperation {
+	b, _ := json.Marshal(v)
+	return PatchOperation{Op: op, Path: path, Value: b}
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
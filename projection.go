@@ -3,10 +3,15 @@ package serialized
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
+// ErrProjectionNotFound is returned when a projection doesn't exist.
+var ErrProjectionNotFound = errors.New("projection not found")
+
 // Projection represents the model used to present your event data.
 type Projection struct {
 	ID   string          `json:"projectionId,omitempty"`
@@ -26,14 +31,33 @@ type EventHandler struct {
 	Functions []*Function `json:"functions,omitempty"`
 }
 
+// FunctionType represents the type of function used to modify a projection.
+type FunctionType string
+
+// Functions used to modify a projection.
+const (
+	FunctionSet      FunctionType = "set"
+	FunctionSetRef   FunctionType = "setref"
+	FunctionInc      FunctionType = "inc"
+	FunctionDec      FunctionType = "dec"
+	FunctionAdd      FunctionType = "add"
+	FunctionSubtract FunctionType = "subtract"
+	FunctionPush     FunctionType = "push"
+	FunctionPrepend  FunctionType = "prepend"
+	FunctionMerge    FunctionType = "merge"
+	FunctionRemove   FunctionType = "remove"
+	FunctionClear    FunctionType = "clear"
+	FunctionDelete   FunctionType = "delete"
+)
+
 // Function contains the templates for modifying projections.
 type Function struct {
-	Function       string      `json:"function,omitempty"`
-	TargetSelector string      `json:"targetSelector,omitempty"`
-	EventSelector  string      `json:"eventSelector,omitempty"`
-	TargetFilter   string      `json:"targetFilter,omitempty"`
-	EventFilter    string      `json:"eventFilter,omitempty"`
-	RawData        interface{} `json:"rawData,omitempty"`
+	Function       FunctionType `json:"function,omitempty"`
+	TargetSelector string       `json:"targetSelector,omitempty"`
+	EventSelector  string       `json:"eventSelector,omitempty"`
+	TargetFilter   string       `json:"targetFilter,omitempty"`
+	EventFilter    string       `json:"eventFilter,omitempty"`
+	RawData        interface{}  `json:"rawData,omitempty"`
 }
 
 // ListProjectionDefinitions returns all definitions.
@@ -48,11 +72,15 @@ func (c *Client) ListProjectionDefinitions(ctx context.Context) ([]*ProjectionDe
 	}
 
 	resp, err := c.do(ctx, req, &response)
+	if err != nil {
+		return nil, err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return response.Definitions, err
+	return response.Definitions, nil
 }
 
 // CreateProjectionDefinition creates a new reaction definition.
@@ -63,11 +91,35 @@ func (c *Client) CreateProjectionDefinition(ctx context.Context, d *ProjectionDe
 	}
 
 	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// UpdateProjectionDefinition replaces an existing projection definition.
+// The projection is rebuilt using the new definition.
+func (c *Client) UpdateProjectionDefinition(ctx context.Context, d *ProjectionDefinition) error {
+	req, err := c.newRequest("PUT", "/projections/definitions/"+d.Name, d)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return err
+	return nil
 }
 
 // ProjectionDefinition returns a projection definition by name.
@@ -80,11 +132,15 @@ func (c *Client) ProjectionDefinition(ctx context.Context, name string) (*Projec
 	var pd ProjectionDefinition
 
 	resp, err := c.do(ctx, req, &pd)
+	if err != nil {
+		return nil, err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return &pd, err
+	return &pd, nil
 }
 
 // DeleteProjectionDefinition deletes a projection definition.
@@ -95,11 +151,15 @@ func (c *Client) DeleteProjectionDefinition(ctx context.Context, name string) er
 	}
 
 	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return err
+	return nil
 }
 
 // SingleProjection returns a single projection for the given aggregate.
@@ -112,30 +172,194 @@ func (c *Client) SingleProjection(ctx context.Context, projName, aggID string) (
 	var proj Projection
 
 	resp, err := c.do(ctx, req, &proj)
+
+	// The body of a missing projection may not be JSON, so the status code is
+	// checked before the error.
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("projection %q for aggregate %q: %w", projName, aggID, ErrProjectionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return &proj, err
+	return &proj, nil
 }
 
-// ListSingleProjections returns all single projections.
-func (c *Client) ListSingleProjections(ctx context.Context, name string) ([]*Projection, error) {
-	req, err := c.newRequest("GET", "/projections/single/"+name, nil)
+// ProjectionPage holds a page of projections.
+type ProjectionPage struct {
+	Projections []*Projection `json:"projections"`
+	HasMore     bool          `json:"hasMore"`
+	TotalCount  int           `json:"totalCount"`
+}
+
+// ListSingleProjections returns single projections. Use WithSkip and
+// WithLimit to page through large projections, or IterateSingleProjections
+// to walk through all of them.
+func (c *Client) ListSingleProjections(ctx context.Context, name string, opts ...ProjectionListOption) ([]*Projection, error) {
+	page, err := c.SingleProjectionsPage(ctx, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Projections, nil
+}
+
+// SingleProjectionsPage returns a page of single projections.
+func (c *Client) SingleProjectionsPage(ctx context.Context, name string, opts ...ProjectionListOption) (*ProjectionPage, error) {
+	return c.singleProjectionsPage(ctx, name, newProjectionListOptions(opts...))
+}
+
+func (c *Client) singleProjectionsPage(ctx context.Context, name string, o ProjectionListOptions) (*ProjectionPage, error) {
+	u := &url.URL{
+		Path:     "/projections/single/" + name,
+		RawQuery: o.values().Encode(),
+	}
+
+	req, err := c.newRequest("GET", u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var response struct {
 		Projections []*Projection `json:"projections"`
+		HasMore     *bool         `json:"hasMore"`
+		TotalCount  int           `json:"totalCount"`
 	}
 
 	resp, err := c.do(ctx, req, &response)
+	if err != nil {
+		return nil, err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return response.Projections, err
+	page := &ProjectionPage{
+		Projections: response.Projections,
+		TotalCount:  response.TotalCount,
+	}
+
+	// Servers that don't tell whether there are more projections are
+	// assumed to have more as long as they return full pages.
+	if response.HasMore != nil {
+		page.HasMore = *response.HasMore
+	} else {
+		page.HasMore = o.Limit > 0 && len(response.Projections) >= o.Limit
+	}
+
+	return page, nil
+}
+
+// defaultPageSize is the number of items requested per page when iterating,
+// unless a limit is given.
+const defaultPageSize = 100
+
+// ProjectionIterator lazily walks through single projections, one page at a
+// time.
+//
+//   it := client.IterateSingleProjections(ctx, "orders", serialized.WithSort("total", true))
+//   for it.Next() {
+//   	p := it.Projection()
+//   	// ...
+//   }
+//   if err := it.Err(); err != nil {
+//   	log.Fatal(err)
+//   }
+type ProjectionIterator struct {
+	ctx    context.Context
+	client *Client
+	name   string
+	opts   ProjectionListOptions
+
+	// limit is the max number of projections to return in total, or 0 for
+	// all of them.
+	limit int
+	seen  int
+
+	page []*Projection
+	cur  *Projection
+	done bool
+	err  error
+}
+
+// IterateSingleProjections returns an iterator over all single projections
+// with the given name. The limit, if given, caps the number of projections
+// returned in total. Use WithPageSize to set the number of projections
+// requested per page.
+func (c *Client) IterateSingleProjections(ctx context.Context, name string, opts ...ProjectionListOption) *ProjectionIterator {
+	o := newProjectionListOptions(opts...)
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+
+	return &ProjectionIterator{
+		ctx:    ctx,
+		client: c,
+		name:   name,
+		opts:   o,
+		limit:  o.Limit,
+	}
+}
+
+// Next advances the iterator to the next projection, fetching the next page
+// if needed. It returns false when there are no more projections or an error
+// occurred.
+func (it *ProjectionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.limit > 0 && it.seen >= it.limit {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		o := it.opts
+		o.Limit = o.PageSize
+		if it.limit > 0 && it.limit-it.seen < o.Limit {
+			o.Limit = it.limit - it.seen
+		}
+
+		page, err := it.client.singleProjectionsPage(it.ctx, it.name, o)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = page.Projections
+		it.opts.Skip += len(page.Projections)
+
+		// Servers may cap the page size below the requested limit, so a
+		// short page doesn't mean there are no more projections.
+		if !page.HasMore || len(page.Projections) == 0 {
+			it.done = true
+		}
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.cur, it.page = it.page[0], it.page[1:]
+	it.seen++
+
+	return true
+}
+
+// Projection returns the current projection.
+func (it *ProjectionIterator) Projection() *Projection {
+	return it.cur
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *ProjectionIterator) Err() error {
+	return it.err
 }
 
 // AggregatedProjection returns an aggregated projection for the given aggregate.
@@ -148,11 +372,20 @@ func (c *Client) AggregatedProjection(ctx context.Context, name string) (*Projec
 	var proj Projection
 
 	resp, err := c.do(ctx, req, &proj)
+
+	// The body of a missing projection may not be JSON, so the status code is
+	// checked before the error.
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("aggregated projection %q: %w", name, ErrProjectionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return &proj, err
+	return &proj, nil
 }
 
 // ListAggregatedProjections returns all single projections.
@@ -167,9 +400,13 @@ func (c *Client) ListAggregatedProjections(ctx context.Context) ([]*Projection,
 	}
 
 	resp, err := c.do(ctx, req, &response)
+	if err != nil {
+		return nil, err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return response.Projections, err
+	return response.Projections, nil
 }
@@ -0,0 +1,324 @@
+package serialized
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProjectionBuilder builds a ProjectionDefinition using a fluent API.
+//
+//   def, err := serialized.NewProjection("orders").
+//   	Feed("order").
+//   	On("OrderPlaced").
+//   	Set("$.projection.status", "$.event.status").
+//   	On("OrderPaid").
+//   	Inc("$.projection.payments").
+//   	Build()
+//
+// Functions are added to the handler of the most recent call to On.
+type ProjectionBuilder struct {
+	def *ProjectionDefinition
+	cur *EventHandler
+	err error
+}
+
+// NewProjection returns a new ProjectionBuilder for a projection with the
+// given name.
+func NewProjection(name string) *ProjectionBuilder {
+	return &ProjectionBuilder{
+		def: &ProjectionDefinition{Name: name},
+	}
+}
+
+// Feed sets the name of the feed the projection is built from.
+func (b *ProjectionBuilder) Feed(name string) *ProjectionBuilder {
+	b.def.Feed = name
+	return b
+}
+
+// On starts a new handler for the given event type.
+func (b *ProjectionBuilder) On(eventType string) *ProjectionBuilder {
+	b.cur = &EventHandler{EventType: eventType}
+	b.def.Handlers = append(b.def.Handlers, b.cur)
+	return b
+}
+
+// Set sets the value at the target selector to the value at the event
+// selector.
+func (b *ProjectionBuilder) Set(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionSet, TargetSelector: target, EventSelector: event})
+}
+
+// SetRaw sets the value at the target selector to a fixed value.
+func (b *ProjectionBuilder) SetRaw(target string, v interface{}) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionSet, TargetSelector: target, RawData: v})
+}
+
+// SetRef sets the reference of the projection to the value at the target
+// selector.
+func (b *ProjectionBuilder) SetRef(target string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionSetRef, TargetSelector: target})
+}
+
+// Inc increments the value at the target selector by one.
+func (b *ProjectionBuilder) Inc(target string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionInc, TargetSelector: target})
+}
+
+// Dec decrements the value at the target selector by one.
+func (b *ProjectionBuilder) Dec(target string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionDec, TargetSelector: target})
+}
+
+// Add adds the value at the event selector to the value at the target
+// selector.
+func (b *ProjectionBuilder) Add(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionAdd, TargetSelector: target, EventSelector: event})
+}
+
+// Subtract subtracts the value at the event selector from the value at the
+// target selector.
+func (b *ProjectionBuilder) Subtract(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionSubtract, TargetSelector: target, EventSelector: event})
+}
+
+// Push appends the value at the event selector to the array at the target
+// selector.
+func (b *ProjectionBuilder) Push(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionPush, TargetSelector: target, EventSelector: event})
+}
+
+// Prepend prepends the value at the event selector to the array at the
+// target selector.
+func (b *ProjectionBuilder) Prepend(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionPrepend, TargetSelector: target, EventSelector: event})
+}
+
+// Merge merges the object at the event selector into the object at the target
+// selector.
+func (b *ProjectionBuilder) Merge(target, event string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionMerge, TargetSelector: target, EventSelector: event})
+}
+
+// Remove removes the value at the target selector.
+func (b *ProjectionBuilder) Remove(target string) *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionRemove, TargetSelector: target})
+}
+
+// Clear clears the projection.
+func (b *ProjectionBuilder) Clear() *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionClear})
+}
+
+// Delete deletes the projection.
+func (b *ProjectionBuilder) Delete() *ProjectionBuilder {
+	return b.add(&Function{Function: FunctionDelete})
+}
+
+// TargetFilter sets the target filter of the most recently added function.
+func (b *ProjectionBuilder) TargetFilter(filter string) *ProjectionBuilder {
+	if f := b.last(); f != nil {
+		f.TargetFilter = filter
+	}
+	return b
+}
+
+// EventFilter sets the event filter of the most recently added function.
+func (b *ProjectionBuilder) EventFilter(filter string) *ProjectionBuilder {
+	if f := b.last(); f != nil {
+		f.EventFilter = filter
+	}
+	return b
+}
+
+// Build validates and returns the projection definition.
+func (b *ProjectionBuilder) Build() (*ProjectionDefinition, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := b.def.Validate(); err != nil {
+		return nil, err
+	}
+	return b.def, nil
+}
+
+func (b *ProjectionBuilder) add(f *Function) *ProjectionBuilder {
+	if b.cur == nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("function %q added before any call to On", f.Function)
+		}
+		return b
+	}
+	b.cur.Functions = append(b.cur.Functions, f)
+	return b
+}
+
+func (b *ProjectionBuilder) last() *Function {
+	if b.cur == nil || len(b.cur.Functions) == 0 {
+		if b.err == nil {
+			b.err = errors.New("filter added before any function")
+		}
+		return nil
+	}
+	return b.cur.Functions[len(b.cur.Functions)-1]
+}
+
+// Validate checks the projection definition for errors before it's sent to
+// the API.
+func (d *ProjectionDefinition) Validate() error {
+	if d.Name == "" {
+		return errors.New("missing projection name")
+	}
+	if d.Feed == "" {
+		return errors.New("missing feed name")
+	}
+	if len(d.Handlers) == 0 {
+		return errors.New("missing handlers")
+	}
+
+	seen := make(map[string]bool)
+	for _, h := range d.Handlers {
+		if h.EventType == "" {
+			return errors.New("handler is missing event type")
+		}
+		if seen[h.EventType] {
+			return fmt.Errorf("duplicate handler for event type %q", h.EventType)
+		}
+		seen[h.EventType] = true
+
+		if len(h.Functions) == 0 {
+			return fmt.Errorf("handler %q: missing functions", h.EventType)
+		}
+		for i, f := range h.Functions {
+			if err := f.Validate(); err != nil {
+				return fmt.Errorf("handler %q: function %d: %s", h.EventType, i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the function has the fields required by its type and
+// that its selectors are well-formed.
+func (f *Function) Validate() error {
+	var needTarget, needValue bool
+
+	switch f.Function {
+	case FunctionSet, FunctionAdd, FunctionSubtract, FunctionPush, FunctionPrepend, FunctionMerge:
+		needTarget, needValue = true, true
+	case FunctionSetRef, FunctionInc, FunctionDec, FunctionRemove:
+		needTarget = true
+	case FunctionClear, FunctionDelete:
+	case "":
+		return errors.New("missing function")
+	default:
+		return fmt.Errorf("unknown function %q", f.Function)
+	}
+
+	if needTarget && f.TargetSelector == "" {
+		return fmt.Errorf("%s requires a target selector", f.Function)
+	}
+	if needValue && f.EventSelector == "" && f.RawData == nil {
+		return fmt.Errorf("%s requires an event selector or raw data", f.Function)
+	}
+	if f.EventSelector != "" && f.RawData != nil {
+		return errors.New("event selector and raw data are mutually exclusive")
+	}
+
+	if f.TargetSelector != "" {
+		if err := validateSelector(f.TargetSelector, "$.projection"); err != nil {
+			return fmt.Errorf("invalid target selector: %s", err)
+		}
+	}
+	if f.EventSelector != "" {
+		if err := validateSelector(f.EventSelector, "$.event"); err != nil {
+			return fmt.Errorf("invalid event selector: %s", err)
+		}
+	}
+
+	if err := validateFilter(f.TargetSelector, f.TargetFilter); err != nil {
+		return fmt.Errorf("invalid target filter: %s", err)
+	}
+	if err := validateFilter(f.EventSelector, f.EventFilter); err != nil {
+		return fmt.Errorf("invalid event filter: %s", err)
+	}
+
+	return nil
+}
+
+// validateSelector checks that sel is a JSONPath expression rooted at root,
+// e.g. $.projection.orders[?].
+func validateSelector(sel, root string) error {
+	if sel != root && !strings.HasPrefix(sel, root+".") && !strings.HasPrefix(sel, root+"[") {
+		return fmt.Errorf("%q must start with %s", sel, root)
+	}
+	return validatePath(strings.TrimPrefix(sel, "$."))
+}
+
+// validatePath checks that path is a dot-separated list of non-empty
+// segments, each optionally followed by bracketed subscripts.
+func validatePath(path string) error {
+	if path == "" {
+		return errors.New("empty path")
+	}
+
+	var depth int
+	var segment int
+	for i, r := range path {
+		switch r {
+		case '[':
+			if depth > 0 {
+				return fmt.Errorf("nested brackets at position %d in %q", i, path)
+			}
+			depth++
+		case ']':
+			if depth == 0 {
+				return fmt.Errorf("unbalanced brackets in %q", path)
+			}
+			depth--
+		case '.':
+			if depth > 0 {
+				continue
+			}
+			if segment == 0 {
+				return fmt.Errorf("empty segment in %q", path)
+			}
+			segment = 0
+			continue
+		case ' ', '\t', '\n':
+			if depth == 0 {
+				return fmt.Errorf("unexpected whitespace in %q", path)
+			}
+		}
+		segment++
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("unbalanced brackets in %q", path)
+	}
+	if segment == 0 {
+		return fmt.Errorf("empty segment in %q", path)
+	}
+
+	return nil
+}
+
+// validateFilter checks that a filter is given if and only if the selector
+// contains a filter placeholder.
+func validateFilter(sel, filter string) error {
+	hasPlaceholder := strings.Contains(sel, "[?]")
+
+	if hasPlaceholder && filter == "" {
+		return fmt.Errorf("selector %q requires a filter", sel)
+	}
+	if !hasPlaceholder && filter != "" {
+		return fmt.Errorf("filter %q given without [?] in selector", filter)
+	}
+	if filter != "" && !strings.HasPrefix(strings.TrimSpace(filter), "@") {
+		return fmt.Errorf("%q must refer to the current element using @", filter)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package serialized
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectionBuilder(t *testing.T) {
+	got, err := NewProjection("orders").
+		Feed("order").
+		On("OrderCancelledEvent").
+		Inc("$.projection.orders[?]").TargetFilter("@.orderId == $.event.orderId").
+		On("OrderPlacedEvent").
+		Set("$.projection.status", "$.event.status").
+		SetRaw("$.projection.cancelled", false).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ProjectionDefinition{
+		Name: "orders",
+		Feed: "order",
+		Handlers: []*EventHandler{
+			{
+				EventType: "OrderCancelledEvent",
+				Functions: []*Function{
+					{
+						Function:       FunctionInc,
+						TargetSelector: "$.projection.orders[?]",
+						TargetFilter:   "@.orderId == $.event.orderId",
+					},
+				},
+			},
+			{
+				EventType: "OrderPlacedEvent",
+				Functions: []*Function{
+					{
+						Function:       FunctionSet,
+						TargetSelector: "$.projection.status",
+						EventSelector:  "$.event.status",
+					},
+					{
+						Function:       FunctionSet,
+						TargetSelector: "$.projection.cancelled",
+						RawData:        false,
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got = %s; want = %s", mustMarshal(got), mustMarshal(want))
+	}
+}
+
+func TestProjectionBuilderFunctionBeforeOn(t *testing.T) {
+	_, err := NewProjection("orders").Feed("order").Inc("$.projection.count").Build()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestProjectionDefinitionValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		builder *ProjectionBuilder
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Set("$.projection.status", "$.event.status"),
+		},
+		{
+			name:    "valid clear",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Clear(),
+		},
+		{
+			name:    "missing feed",
+			builder: NewProjection("orders").On("OrderPlaced").Inc("$.projection.count"),
+			wantErr: true,
+		},
+		{
+			name:    "missing handlers",
+			builder: NewProjection("orders").Feed("order"),
+			wantErr: true,
+		},
+		{
+			name:    "missing functions",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced"),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate handlers",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Inc("$.projection.a").On("OrderPlaced").Inc("$.projection.b"),
+			wantErr: true,
+		},
+		{
+			name:    "wrong target root",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Set("$.event.status", "$.event.status"),
+			wantErr: true,
+		},
+		{
+			name:    "wrong event root",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Set("$.projection.status", "$.status"),
+			wantErr: true,
+		},
+		{
+			name:    "empty segment",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Inc("$.projection..count"),
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced brackets",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Inc("$.projection.orders[?"),
+			wantErr: true,
+		},
+		{
+			name:    "missing filter",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Inc("$.projection.orders[?]"),
+			wantErr: true,
+		},
+		{
+			name:    "filter without placeholder",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Inc("$.projection.count").TargetFilter("@.id == 1"),
+			wantErr: true,
+		},
+		{
+			name:    "missing event selector",
+			builder: NewProjection("orders").Feed("order").On("OrderPlaced").Add("$.projection.total", ""),
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFunctionValidateUnknown(t *testing.T) {
+	f := &Function{Function: "frobnicate", TargetSelector: "$.projection.foo"}
+	if err := f.Validate(); err == nil {
+		t.Fatal("expected error")
+	}
+}
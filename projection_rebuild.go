@@ -0,0 +1,176 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RebuildProgress reports how far a projection rebuild has come.
+type RebuildProgress struct {
+	// Current is the sequence number of the last feed entry processed by
+	// the projection.
+	Current int64
+
+	// Target is the sequence number at head of the feed when the rebuild
+	// started.
+	Target int64
+}
+
+// Done reports whether the projection has caught up with the feed.
+func (p RebuildProgress) Done() bool {
+	return p.Current >= p.Target
+}
+
+// Percent returns the progress as a percentage between 0 and 100.
+func (p RebuildProgress) Percent() float64 {
+	if p.Target <= 0 || p.Done() {
+		return 100
+	}
+	if p.Current <= 0 {
+		return 0
+	}
+	return float64(p.Current) / float64(p.Target) * 100
+}
+
+// ErrRebuildProgressUnavailable is returned by RebuildProjection when the
+// projection was recreated, but the server doesn't report how far it has come.
+var ErrRebuildProgressUnavailable = errors.New("projection was recreated, but its progress isn't available")
+
+// rebuildCreateAttempts is the number of times RebuildProjection tries to
+// recreate a deleted definition before giving up.
+const rebuildCreateAttempts = 5
+
+// RecreateProjectionError is returned by RebuildProjection when a definition
+// was deleted, but couldn't be recreated.
+type RecreateProjectionError struct {
+	// Definition is the deleted definition, encoded as JSON, so that it can
+	// be recreated by hand.
+	Definition []byte
+	Err        error
+}
+
+func (e *RecreateProjectionError) Error() string {
+	return fmt.Sprintf("unable to recreate projection definition: %s. The deleted definition was: %s", e.Err, e.Definition)
+}
+
+// Unwrap returns the error from the last attempt to recreate the definition.
+func (e *RecreateProjectionError) Unwrap() error {
+	return e.Err
+}
+
+// RebuildProjection deletes and recreates a projection definition, causing
+// the projection to be rebuilt from the beginning of its feed. The given
+// function is called every poll interval with the current progress, until
+// the projection has caught up with the head of the feed at the time the
+// definition was recreated.
+//
+// If recreating the definition fails, it's retried with an increasing delay.
+// If every attempt fails, a *RecreateProjectionError holding the deleted
+// definition is returned.
+//
+// The progress of the projection is read from the
+// Serialized-Sequencenumber-Current header of a HEAD request for the
+// definition. That request isn't part of the documented API, so servers that
+// don't respond to it cause ErrRebuildProgressUnavailable to be returned once
+// the definition has been recreated.
+func (c *Client) RebuildProjection(ctx context.Context, name string, fn func(RebuildProgress)) error {
+	def, err := c.ProjectionDefinition(ctx, name)
+	if err != nil {
+		return fmt.Errorf("unable to get projection definition: %s", err)
+	}
+
+	if err := c.DeleteProjectionDefinition(ctx, name); err != nil {
+		return fmt.Errorf("unable to delete projection definition: %s", err)
+	}
+
+	if err := c.recreateProjectionDefinition(ctx, def); err != nil {
+		return err
+	}
+
+	target, err := c.FeedSequenceNumber(ctx, def.Feed)
+	if err != nil {
+		return fmt.Errorf("unable to get sequence number: %s", err)
+	}
+
+	for {
+		cur, err := c.projectionSequenceNumber(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		p := RebuildProgress{Current: cur, Target: target}
+
+		if fn != nil {
+			fn(p)
+		}
+
+		if p.Done() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
+	}
+}
+
+// recreateProjectionDefinition creates a definition that has just been
+// deleted, retrying failed attempts with a delay that starts at the poll
+// interval and doubles for each attempt, until the context is done.
+func (c *Client) recreateProjectionDefinition(ctx context.Context, def *ProjectionDefinition) error {
+	var err error
+
+	delay := c.pollInterval
+	for i := 0; i < rebuildCreateAttempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+
+		if err = c.CreateProjectionDefinition(ctx, def); err == nil {
+			return nil
+		}
+	}
+
+	b, merr := json.Marshal(def)
+	if merr != nil {
+		b = []byte(fmt.Sprintf("%+v", def))
+	}
+
+	return &RecreateProjectionError{Definition: b, Err: err}
+}
+
+// projectionSequenceNumber returns the sequence number of the last feed entry
+// processed by a projection.
+func (c *Client) projectionSequenceNumber(ctx context.Context, name string) (int64, error) {
+	req, err := c.newRequest("HEAD", "/projections/definitions/"+name, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	seqstr := resp.Header.Get("Serialized-Sequencenumber-Current")
+	if resp.StatusCode != http.StatusOK || seqstr == "" {
+		return 0, ErrRebuildProgressUnavailable
+	}
+
+	return strconv.ParseInt(seqstr, 10, 64)
+}
@@ -0,0 +1,155 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRebuildProjection(t *testing.T) {
+	var (
+		deleted, created bool
+		seq              int64
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projections/definitions/orders":
+			b, err := loadJSON("testdata/projection_get_definition_response.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(b); err != nil {
+				t.Fatal(err)
+			}
+		case r.Method == "HEAD" && r.URL.Path == "/feeds/order":
+			w.Header().Set("Serialized-Sequencenumber-Current", "10")
+		case r.Method == "DELETE" && r.URL.Path == "/projections/definitions/orders":
+			deleted = true
+		case r.Method == "POST" && r.URL.Path == "/projections/definitions":
+			if !deleted {
+				t.Fatal("definition was recreated before it was deleted")
+			}
+			created = true
+		case r.Method == "HEAD" && r.URL.Path == "/projections/definitions/orders":
+			seq += 4
+			w.Header().Set("Serialized-Sequencenumber-Current", strconv.FormatInt(seq, 10))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	var progress []RebuildProgress
+
+	err := c.RebuildProjection(context.Background(), "orders", func(p RebuildProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !deleted || !created {
+		t.Fatal("definition was not recreated")
+	}
+	if len(progress) != 3 {
+		t.Fatalf("unexpected number of progress reports = %d; want = %d", len(progress), 3)
+	}
+	if got := progress[0].Percent(); got != 40 {
+		t.Errorf("unexpected percent = %f; want = %d", got, 40)
+	}
+	if !progress[2].Done() {
+		t.Errorf("rebuild should be done")
+	}
+}
+
+func TestRebuildProjectionMissingSequenceNumber(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projections/definitions/orders":
+			b, err := loadJSON("testdata/projection_get_definition_response.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(b); err != nil {
+				t.Fatal(err)
+			}
+		case r.Method == "HEAD" && r.URL.Path == "/feeds/order":
+			w.Header().Set("Serialized-Sequencenumber-Current", "10")
+		case r.Method == "DELETE" && r.URL.Path == "/projections/definitions/orders":
+		case r.Method == "POST" && r.URL.Path == "/projections/definitions":
+		case r.Method == "HEAD" && r.URL.Path == "/projections/definitions/orders":
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := c.RebuildProjection(ctx, "orders", nil)
+	if err != ErrRebuildProgressUnavailable {
+		t.Fatalf("unexpected error = %v; want = %v", err, ErrRebuildProgressUnavailable)
+	}
+}
+
+func TestRebuildProjectionRecreateFailure(t *testing.T) {
+	var attempts int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projections/definitions/orders":
+			b, err := loadJSON("testdata/projection_get_definition_response.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(b); err != nil {
+				t.Fatal(err)
+			}
+		case r.Method == "DELETE" && r.URL.Path == "/projections/definitions/orders":
+		case r.Method == "POST" && r.URL.Path == "/projections/definitions":
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	err := c.RebuildProjection(context.Background(), "orders", nil)
+
+	var rerr *RecreateProjectionError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("unexpected error = %v; want = %T", err, rerr)
+	}
+	if attempts != rebuildCreateAttempts {
+		t.Errorf("unexpected number of attempts = %d; want = %d", attempts, rebuildCreateAttempts)
+	}
+
+	var def ProjectionDefinition
+	if err := json.Unmarshal(rerr.Definition, &def); err != nil {
+		t.Fatal(err)
+	}
+	if def.Name != "orders" || def.Feed != "order" {
+		t.Errorf("unexpected definition = %s", rerr.Definition)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -82,6 +84,54 @@ func TestProjectionCreateDefinition(t *testing.T) {
 	}
 }
 
+func TestProjectionUpdateDefinition(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Fatalf("unexpected method = %s; want = %s", r.Method, "PUT")
+		}
+		if r.URL.Path != "/projections/definitions/orders" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		got, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := loadJSON("testdata/projection_create_definition_request.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqualJSON(t, got, want)
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	def := &ProjectionDefinition{
+		Name: "orders",
+		Feed: "order",
+		Handlers: []*EventHandler{
+			{
+				EventType: "OrderCancelledEvent",
+				Functions: []*Function{
+					{
+						Function:       "inc",
+						TargetSelector: "$.projection.orders[?]",
+						EventSelector:  "$.event[?]",
+						TargetFilter:   "@.orderId == $.event.orderId",
+						EventFilter:    "@.orderAmount > 4000",
+					},
+				},
+			},
+		},
+	}
+
+	if err := c.UpdateProjectionDefinition(context.Background(), def); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestProjectionGetDefinition(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		b, err := loadJSON("testdata/projection_get_definition_response.json")
@@ -201,6 +251,207 @@ func TestProjectionListSingle(t *testing.T) {
 	}
 }
 
+func TestProjectionListSingleOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := url.Values{
+			"skip":      []string{"10"},
+			"limit":     []string{"5"},
+			"sort":      []string{"-total"},
+			"reference": []string{"customer-1"},
+		}
+		if got := r.URL.Query(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected query = %v; want = %v", got, want)
+		}
+		if _, err := w.Write([]byte(`{"projections":[]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	_, err := c.ListSingleProjections(context.Background(), "orders",
+		WithSkip(10),
+		WithLimit(5),
+		WithSort("total", true),
+		WithReference("customer-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectionIterateSingle(t *testing.T) {
+	var requests int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var page ProjectionPage
+		switch r.URL.Query().Get("skip") {
+		case "":
+			page.Projections = []*Projection{{ID: "1"}, {ID: "2"}}
+			page.HasMore = true
+		case "2":
+			page.Projections = []*Projection{{ID: "3"}}
+		default:
+			t.Fatalf("unexpected skip: %s", r.URL.Query().Get("skip"))
+		}
+		if _, err := w.Write(mustMarshal(page)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	it := c.IterateSingleProjections(context.Background(), "orders", WithPageSize(2))
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Projection().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got = %v; want = %v", ids, want)
+	}
+	if requests != 2 {
+		t.Fatalf("unexpected number of requests = %d; want = %d", requests, 2)
+	}
+}
+
+func TestProjectionIterateSingleWithoutHasMore(t *testing.T) {
+	all := []string{"1", "2", "3", "4", "5"}
+
+	var requests int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		end := skip + limit
+		if end > len(all) {
+			end = len(all)
+		}
+
+		// The response doesn't include hasMore.
+		projs := []*Projection{}
+		for _, id := range all[skip:end] {
+			projs = append(projs, &Projection{ID: id})
+		}
+		if _, err := w.Write(mustMarshal(map[string]interface{}{"projections": projs})); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	it := c.IterateSingleProjections(context.Background(), "orders", WithPageSize(2))
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Projection().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ids, all) {
+		t.Fatalf("got = %v; want = %v", ids, all)
+	}
+	if requests != 3 {
+		t.Fatalf("unexpected number of requests = %d; want = %d", requests, 3)
+	}
+}
+
+func TestProjectionIterateSingleCappedPages(t *testing.T) {
+	all := []*Projection{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+
+	// The server returns at most two projections per page, regardless of
+	// the requested limit.
+	const serverMax = 2
+
+	var limits []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits = append(limits, r.URL.Query().Get("limit"))
+
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit > serverMax {
+			limit = serverMax
+		}
+
+		var page ProjectionPage
+		end := skip + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		page.Projections = all[skip:end]
+		page.HasMore = end < len(all)
+
+		if _, err := w.Write(mustMarshal(page)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	var tests = []struct {
+		name   string
+		opts   []ProjectionListOption
+		want   []string
+		limits []string
+	}{
+		{
+			name:   "all",
+			opts:   []ProjectionListOption{WithPageSize(10)},
+			want:   []string{"1", "2", "3", "4", "5"},
+			limits: []string{"10", "10", "10"},
+		},
+		{
+			name:   "limit",
+			opts:   []ProjectionListOption{WithPageSize(10), WithLimit(3)},
+			want:   []string{"1", "2", "3"},
+			limits: []string{"3", "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits = nil
+
+			it := c.IterateSingleProjections(context.Background(), "orders", tt.opts...)
+
+			var ids []string
+			for it.Next() {
+				ids = append(ids, it.Projection().ID)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Fatalf("got = %v; want = %v", ids, tt.want)
+			}
+			if !reflect.DeepEqual(limits, tt.limits) {
+				t.Fatalf("limits = %v; want = %v", limits, tt.limits)
+			}
+		})
+	}
+}
+
 func TestProjectionGetAggregated(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		b, err := loadJSON("testdata/projection_get_agg_response.json")
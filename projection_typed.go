@@ -0,0 +1,112 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedProjection is a projection with its data decoded into T.
+type TypedProjection[T any] struct {
+	ID   string
+	Data T
+}
+
+// ProjectionDecodeError is returned when the data of a projection can't be
+// decoded into the requested type.
+type ProjectionDecodeError struct {
+	Name string
+	ID   string
+	Err  error
+}
+
+func (e *ProjectionDecodeError) Error() string {
+	switch {
+	case e.Name == "":
+		return fmt.Sprintf("unable to decode projection with id %q: %s", e.ID, e.Err)
+	case e.ID == "":
+		return fmt.Sprintf("unable to decode projection %q: %s", e.Name, e.Err)
+	}
+	return fmt.Sprintf("unable to decode projection %q with id %q: %s", e.Name, e.ID, e.Err)
+}
+
+// Unwrap returns the underlying decode error.
+func (e *ProjectionDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// GetSingleProjection returns the data of a single projection for the given
+// aggregate, decoded into T. If the projection doesn't exist, the returned
+// error wraps ErrProjectionNotFound.
+func GetSingleProjection[T any](ctx context.Context, c *Client, name, aggID string) (T, error) {
+	var v T
+
+	proj, err := c.SingleProjection(ctx, name, aggID)
+	if err != nil {
+		return v, err
+	}
+
+	return decodeProjection[T](name, proj)
+}
+
+// GetAggregatedProjection returns the data of an aggregated projection,
+// decoded into T. If the projection doesn't exist, the returned error wraps
+// ErrProjectionNotFound.
+func GetAggregatedProjection[T any](ctx context.Context, c *Client, name string) (T, error) {
+	var v T
+
+	proj, err := c.AggregatedProjection(ctx, name)
+	if err != nil {
+		return v, err
+	}
+
+	return decodeProjection[T](name, proj)
+}
+
+// ListSingleProjections returns all single projections with the given name,
+// with their data decoded into T.
+func ListSingleProjections[T any](ctx context.Context, c *Client, name string, opts ...ProjectionListOption) ([]*TypedProjection[T], error) {
+	projs, err := c.ListSingleProjections(ctx, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProjections[T](name, projs)
+}
+
+// ListAggregatedProjections returns all aggregated projections, with their
+// data decoded into T.
+func ListAggregatedProjections[T any](ctx context.Context, c *Client) ([]*TypedProjection[T], error) {
+	projs, err := c.ListAggregatedProjections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProjections[T]("", projs)
+}
+
+func decodeProjections[T any](name string, projs []*Projection) ([]*TypedProjection[T], error) {
+	res := make([]*TypedProjection[T], 0, len(projs))
+	for _, p := range projs {
+		v, err := decodeProjection[T](name, p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &TypedProjection[T]{ID: p.ID, Data: v})
+	}
+	return res, nil
+}
+
+func decodeProjection[T any](name string, proj *Projection) (T, error) {
+	var v T
+
+	if len(proj.Data) == 0 {
+		return v, nil
+	}
+
+	if err := json.Unmarshal(proj.Data, &v); err != nil {
+		return v, &ProjectionDecodeError{Name: name, ID: proj.ID, Err: err}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,110 @@
+package serialized
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testOrderTotal struct {
+	Field string `json:"field"`
+}
+
+func TestGetSingleProjection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projections/single/orders/foo" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		b, err := loadJSON("testdata/projection_get_single_response.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	got, err := GetSingleProjection[testOrderTotal](context.Background(), c, "orders", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "data"; got.Field != want {
+		t.Fatalf("want = %s; got = %s", want, got.Field)
+	}
+
+	m, err := GetSingleProjection[map[string]any](context.Background(), c, "orders", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "data"; m["field"] != want {
+		t.Fatalf("want = %s; got = %v", want, m["field"])
+	}
+}
+
+func TestGetSingleProjectionNotFound(t *testing.T) {
+	for _, body := range []string{"", "<html>Not Found</html>", `{"message":"not found"}`} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(body))
+		}))
+		defer ts.Close()
+
+		c := NewClient(
+			WithBaseURL(ts.URL),
+		)
+
+		_, err := GetSingleProjection[testOrderTotal](context.Background(), c, "orders", "foo")
+		if !errors.Is(err, ErrProjectionNotFound) {
+			t.Fatalf("body = %q: unexpected error = %v; want = %v", body, err, ErrProjectionNotFound)
+		}
+
+		_, err = GetAggregatedProjection[testOrderTotal](context.Background(), c, "orders")
+		if !errors.Is(err, ErrProjectionNotFound) {
+			t.Fatalf("body = %q: unexpected error = %v; want = %v", body, err, ErrProjectionNotFound)
+		}
+	}
+}
+
+func TestListSingleProjectionsDecodeError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := loadJSON("testdata/projection_list_single_response.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	// The test data contains a string rather than an object.
+	_, err := ListSingleProjections[testOrderTotal](context.Background(), c, "foo")
+
+	var decodeErr *ProjectionDecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("unexpected error = %v; want = %T", err, decodeErr)
+	}
+	if decodeErr.ID != "string (uuid)" {
+		t.Fatalf("unexpected projection id: %s", decodeErr.ID)
+	}
+
+	projs, err := ListSingleProjections[string](context.Background(), c, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projs) != 1 {
+		t.Fatalf("unexpected number of projections = %d; want = %d", len(projs), 1)
+	}
+	if want := "object"; projs[0].Data != want {
+		t.Fatalf("want = %s; got = %s", want, projs[0].Data)
+	}
+}
@@ -0,0 +1,168 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ProjectionChange describes a change in the data of a projection.
+type ProjectionChange struct {
+	ID    string
+	Old   json.RawMessage
+	New   json.RawMessage
+	Patch []PatchOperation
+}
+
+// WatchOptions holds the options for watching projections.
+type WatchOptions struct {
+	FollowFeed bool
+}
+
+// WithFollowFeed makes the watcher follow the feed of the projection, and
+// only read the projection when new events arrive, rather than reading it
+// every poll interval.
+//
+// Projections are updated asynchronously, so a change caused by an event
+// may be reported first when the next event arrives.
+func WithFollowFeed() func(*WatchOptions) {
+	return func(o *WatchOptions) {
+		o.FollowFeed = true
+	}
+}
+
+// WatchSingleProjection runs the given function whenever the data of the
+// single projection for the given aggregate changes. If the projection
+// exists, the function is called once with its initial data. This call
+// blocks until the provided context is cancelled.
+func (c *Client) WatchSingleProjection(ctx context.Context, name, aggID string, fn func(*ProjectionChange), opts ...func(*WatchOptions)) error {
+	w := &projectionWatcher{
+		fetch: func(ctx context.Context) (*Projection, error) {
+			return c.SingleProjection(ctx, name, aggID)
+		},
+		fn: fn,
+	}
+
+	return c.watchProjection(ctx, name, aggID, w, opts...)
+}
+
+// WatchAggregatedProjection runs the given function whenever the data of the
+// aggregated projection changes. If the projection exists, the function is
+// called once with its initial data. This call blocks until the provided
+// context is cancelled.
+func (c *Client) WatchAggregatedProjection(ctx context.Context, name string, fn func(*ProjectionChange), opts ...func(*WatchOptions)) error {
+	w := &projectionWatcher{
+		fetch: func(ctx context.Context) (*Projection, error) {
+			return c.AggregatedProjection(ctx, name)
+		},
+		fn: fn,
+	}
+
+	return c.watchProjection(ctx, name, "", w, opts...)
+}
+
+func (c *Client) watchProjection(ctx context.Context, name, aggID string, w *projectionWatcher, opts ...func(*WatchOptions)) error {
+	var o WatchOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	if err := w.check(ctx); err != nil {
+		return err
+	}
+
+	if !o.FollowFeed {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.pollInterval):
+				if err := w.check(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	def, err := c.ProjectionDefinition(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	seq, err := c.FeedSequenceNumber(ctx, def.Feed)
+	if err != nil {
+		return err
+	}
+
+	feedctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var checkErr error
+
+	err = c.Feed(feedctx, def.Feed, seq, func(e *FeedEntry) {
+		if aggID != "" && e.AggregateID != aggID {
+			return
+		}
+		if err := w.check(feedctx); err != nil {
+			checkErr = err
+			cancel()
+		}
+	})
+	if checkErr != nil {
+		return checkErr
+	}
+
+	return err
+}
+
+type projectionWatcher struct {
+	fetch func(ctx context.Context) (*Projection, error)
+	fn    func(*ProjectionChange)
+
+	id   string
+	data json.RawMessage
+	cur  interface{}
+}
+
+// check reads the projection and runs the watch function if its data has
+// changed since the last check.
+func (w *projectionWatcher) check(ctx context.Context) error {
+	proj, err := w.fetch(ctx)
+	if errors.Is(err, ErrProjectionNotFound) {
+		proj, err = &Projection{ID: w.id}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	if len(proj.Data) > 0 {
+		if err := json.Unmarshal(proj.Data, &v); err != nil {
+			return err
+		}
+	}
+
+	if (len(proj.Data) > 0) == (len(w.data) > 0) && reflect.DeepEqual(v, w.cur) {
+		return nil
+	}
+
+	patch, err := DiffJSON(w.data, proj.Data)
+	if err != nil {
+		return err
+	}
+
+	change := &ProjectionChange{
+		ID:    proj.ID,
+		Old:   w.data,
+		New:   proj.Data,
+		Patch: patch,
+	}
+
+	w.id, w.data, w.cur = proj.ID, proj.Data, v
+
+	w.fn(change)
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchSingleProjection(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		responses = []string{
+			`{"projectionId":"foo","data":{"status":"PLACED","items":[1]}}`,
+			`{"projectionId":"foo","data":{"items":[1],"status":"PLACED"}}`,
+			`{"projectionId":"foo","data":{"status":"PAID","items":[1,2]}}`,
+		}
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projections/single/orders/foo" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := w.Write([]byte(responses[0])); err != nil {
+			t.Fatal(err)
+		}
+		responses = responses[1:]
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var changes []*ProjectionChange
+
+	err := c.WatchSingleProjection(ctx, "orders", "foo", func(change *ProjectionChange) {
+		changes = append(changes, change)
+		if len(changes) == 3 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("unexpected number of changes = %d; want = %d", len(changes), 3)
+	}
+
+	if changes[0].Old != nil {
+		t.Errorf("unexpected old data in initial change = %s", changes[0].Old)
+	}
+
+	got := changes[1].Patch
+	want := []PatchOperation{
+		{Op: "add", Path: "/items/1", Value: json.RawMessage(`2`)},
+		{Op: "replace", Path: "/status", Value: json.RawMessage(`"PAID"`)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %s; want = %s", mustMarshal(got), mustMarshal(want))
+	}
+
+	if changes[2].New != nil {
+		t.Errorf("unexpected new data after removal = %s", changes[2].New)
+	}
+	if want := []PatchOperation{{Op: "remove", Path: ""}}; !reflect.DeepEqual(changes[2].Patch, want) {
+		t.Errorf("got = %s; want = %s", mustMarshal(changes[2].Patch), mustMarshal(want))
+	}
+}
+
+func TestWatchSingleProjectionFollowFeed(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		reads int
+
+		// The projection is updated asynchronously, so it hasn't changed
+		// yet when the first event of the aggregate arrives.
+		responses = []string{
+			`{"projectionId":"foo","data":{"status":"PLACED"}}`,
+			`{"projectionId":"foo","data":{"status":"PLACED"}}`,
+			`{"projectionId":"foo","data":{"status":"PAID"}}`,
+		}
+
+		entries = map[string]string{
+			"10": `{"entries":[{"sequenceNumber":11,"aggregateId":"bar"},{"sequenceNumber":12,"aggregateId":"foo"}]}`,
+			"12": `{"entries":[{"sequenceNumber":13,"aggregateId":"foo"}]}`,
+			"13": `{"entries":[]}`,
+		}
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/projections/definitions/orders":
+			w.Write([]byte(`{"projectionName":"orders","feedName":"order"}`))
+		case r.URL.Path == "/feeds/order" && r.Method == "HEAD":
+			w.Header().Set("Serialized-Sequencenumber-Current", "10")
+		case r.URL.Path == "/feeds/order":
+			b, ok := entries[r.URL.Query().Get("since")]
+			if !ok {
+				t.Fatalf("unexpected since: %s", r.URL.Query().Get("since"))
+			}
+			w.Write([]byte(b))
+		case r.URL.Path == "/projections/single/orders/foo":
+			if len(responses) == 0 {
+				t.Fatalf("unexpected read of projection")
+			}
+			reads++
+			w.Write([]byte(responses[0]))
+			responses = responses[1:]
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var changes []*ProjectionChange
+
+	err := c.WatchSingleProjection(ctx, "orders", "foo", func(change *ProjectionChange) {
+		changes = append(changes, change)
+		if len(changes) == 2 {
+			cancel()
+		}
+	}, WithFollowFeed())
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	// The projection is read initially and for each event of the aggregate,
+	// but not for events of other aggregates.
+	mu.Lock()
+	defer mu.Unlock()
+	if reads != 3 {
+		t.Fatalf("unexpected number of reads = %d; want = %d", reads, 3)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("unexpected number of changes = %d; want = %d", len(changes), 2)
+	}
+
+	want := []PatchOperation{
+		{Op: "replace", Path: "/status", Value: json.RawMessage(`"PAID"`)},
+	}
+	if !reflect.DeepEqual(changes[1].Patch, want) {
+		t.Errorf("got = %s; want = %s", mustMarshal(changes[1].Patch), mustMarshal(want))
+	}
+}
+
+func TestWatchAggregatedProjection(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		responses = []string{
+			`{"projectionId":"totals","data":{"count":1}}`,
+			`{"projectionId":"totals","data":{"count":1}}`,
+			`{"projectionId":"totals","data":{"count":2}}`,
+		}
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projections/aggregated/totals" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, err := w.Write([]byte(responses[0])); err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) > 1 {
+			responses = responses[1:]
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithPollInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var changes []*ProjectionChange
+
+	err := c.WatchAggregatedProjection(ctx, "totals", func(change *ProjectionChange) {
+		changes = append(changes, change)
+		if len(changes) == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("unexpected number of changes = %d; want = %d", len(changes), 2)
+	}
+
+	if got, want := string(changes[0].New), `{"count":1}`; got != want {
+		t.Errorf("got = %s; want = %s", got, want)
+	}
+
+	want := []PatchOperation{
+		{Op: "replace", Path: "/count", Value: json.RawMessage(`2`)},
+	}
+	if !reflect.DeepEqual(changes[1].Patch, want) {
+		t.Errorf("got = %s; want = %s", mustMarshal(changes[1].Patch), mustMarshal(want))
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want []PatchOperation
+	}{
+		{
+			a:    `{"a":1}`,
+			b:    `{"a":1}`,
+			want: nil,
+		},
+		{
+			a: `{"a":1,"b":{"c":[1,2,3]}}`,
+			b: `{"b":{"c":[1]},"d/e":null}`,
+			want: []PatchOperation{
+				{Op: "remove", Path: "/a"},
+				{Op: "remove", Path: "/b/c/2"},
+				{Op: "remove", Path: "/b/c/1"},
+				{Op: "add", Path: "/d~1e", Value: json.RawMessage(`null`)},
+			},
+		},
+		{
+			a: `[1]`,
+			b: `{"a":1}`,
+			want: []PatchOperation{
+				{Op: "replace", Path: "", Value: json.RawMessage(`{"a":1}`)},
+			},
+		},
+	} {
+		got, err := DiffJSON([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diff(%s, %s) = %s; want = %s", tt.a, tt.b, mustMarshal(got), mustMarshal(tt.want))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package serialized
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -23,15 +24,70 @@ type ActionType string
 
 // Valid action types.
 const (
-	ActionTypeHTTPPost  ActionType = "HTTP_POST"
-	ActionTypeSlackPost ActionType = "SLACK_POST"
+	ActionTypeHTTPPost   ActionType = "HTTP_POST"
+	ActionTypeSlackPost  ActionType = "SLACK_POST"
+	ActionTypeIFTTTPost  ActionType = "IFTTT_POST"
+	ActionTypeZapierPost ActionType = "ZAPIER_POST"
+	ActionTypeEmail      ActionType = "EMAIL"
 )
 
 // An Action defines what will happen when the reaction is triggered.
+//
+// Config holds additional configuration specific to the action type, and
+// must be of the matching type, e.g. *HTTPConfig for HTTP_POST actions. It's
+// encoded alongside the other fields of the action.
 type Action struct {
-	ActionType ActionType `json:"actionType,omitempty"`
-	TargetURI  string     `json:"targetUri,omitempty"`
-	Body       string     `json:"body,omitempty"`
+	ActionType ActionType   `json:"actionType,omitempty"`
+	TargetURI  string       `json:"targetUri,omitempty"`
+	Body       string       `json:"body,omitempty"`
+	Config     ActionConfig `json:"-"`
+
+	// unknown holds the fields of a decoded action that belong to neither
+	// the action nor its configuration.
+	unknown []string
+}
+
+// Validate checks the reaction definition for errors before it's sent to the
+// API.
+func (r *ReactionDefinition) Validate() error {
+	if r.Name == "" {
+		return errors.New("missing reaction name")
+	}
+	if r.Feed == "" {
+		return errors.New("missing feed name")
+	}
+	if r.ReactOnEventType == "" {
+		return errors.New("missing event type to react on")
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range r.CancelOnEventTypes {
+		if t == r.ReactOnEventType {
+			return fmt.Errorf("event type %q is both reacted on and cancelled on", t)
+		}
+		if seen[t] {
+			return fmt.Errorf("duplicate cancel event type %q", t)
+		}
+		seen[t] = true
+	}
+
+	if r.TriggerTimeField != "" {
+		if err := validatePath(r.TriggerTimeField); err != nil {
+			return fmt.Errorf("invalid trigger time field: %s", err)
+		}
+	}
+
+	if r.Offset != "" {
+		if _, err := ParseDuration(r.Offset); err != nil {
+			return fmt.Errorf("invalid offset: %s", err)
+		}
+	}
+
+	if r.Action == nil {
+		return errors.New("missing action")
+	}
+
+	return r.Action.Validate()
 }
 
 // CreateReactionDefinition registers a new reaction definition.
@@ -42,11 +98,34 @@ func (c *Client) CreateReactionDefinition(ctx context.Context, r *ReactionDefini
 	}
 
 	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return err
+	return nil
+}
+
+// UpdateReactionDefinition replaces an existing reaction definition.
+func (c *Client) UpdateReactionDefinition(ctx context.Context, r *ReactionDefinition) error {
+	req, err := c.newRequest("PUT", "/reactions/definitions/"+r.Name, r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
 }
 
 // ListReactionDefinitions returns all registered reactions.
@@ -61,11 +140,15 @@ func (c *Client) ListReactionDefinitions(ctx context.Context) ([]*ReactionDefini
 	}
 
 	resp, err := c.do(ctx, req, &response)
+	if err != nil {
+		return nil, err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return response.Definitions, err
+	return response.Definitions, nil
 }
 
 // DeleteReactionDefinition deletes a reaction with a given name.
@@ -0,0 +1,316 @@
+package serialized
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ActionConfig holds the configuration specific to an action type.
+type ActionConfig interface {
+	// ActionType returns the action type the configuration applies to.
+	ActionType() ActionType
+
+	validate(a *Action) error
+}
+
+// HTTPConfig configures HTTP_POST actions.
+type HTTPConfig struct {
+	// Headers are added to the request.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// SigningSecret is used to sign the body of the request. The
+	// signature is sent in the SignatureHeader.
+	SigningSecret string `json:"signingSecret,omitempty"`
+}
+
+// SlackConfig configures SLACK_POST actions.
+type SlackConfig struct {
+	Channel string `json:"channel,omitempty"`
+
+	// Blocks are Slack layout blocks sent along with the message text.
+	Blocks []json.RawMessage `json:"blocks,omitempty"`
+}
+
+// IFTTTConfig configures IFTTT_POST actions. The values are templates
+// rendered using the event that triggered the reaction.
+type IFTTTConfig struct {
+	Value1 string `json:"value1,omitempty"`
+	Value2 string `json:"value2,omitempty"`
+	Value3 string `json:"value3,omitempty"`
+}
+
+// ZapierConfig configures ZAPIER_POST actions. The fields are templates
+// rendered using the event that triggered the reaction.
+type ZapierConfig struct {
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// EmailConfig configures EMAIL actions. The body of the action is used as
+// the body of the email.
+type EmailConfig struct {
+	To      []string `json:"to,omitempty"`
+	Cc      []string `json:"cc,omitempty"`
+	Subject string   `json:"subject,omitempty"`
+}
+
+// ActionType returns ActionTypeHTTPPost.
+func (*HTTPConfig) ActionType() ActionType { return ActionTypeHTTPPost }
+
+// ActionType returns ActionTypeSlackPost.
+func (*SlackConfig) ActionType() ActionType { return ActionTypeSlackPost }
+
+// ActionType returns ActionTypeIFTTTPost.
+func (*IFTTTConfig) ActionType() ActionType { return ActionTypeIFTTTPost }
+
+// ActionType returns ActionTypeZapierPost.
+func (*ZapierConfig) ActionType() ActionType { return ActionTypeZapierPost }
+
+// ActionType returns ActionTypeEmail.
+func (*EmailConfig) ActionType() ActionType { return ActionTypeEmail }
+
+func newActionConfig(t ActionType) ActionConfig {
+	switch t {
+	case ActionTypeHTTPPost:
+		return new(HTTPConfig)
+	case ActionTypeSlackPost:
+		return new(SlackConfig)
+	case ActionTypeIFTTTPost:
+		return new(IFTTTConfig)
+	case ActionTypeZapierPost:
+		return new(ZapierConfig)
+	case ActionTypeEmail:
+		return new(EmailConfig)
+	}
+	return nil
+}
+
+// plainAction has the fields of Action but none of its methods.
+type plainAction Action
+
+// plainActionFields are the JSON fields of an action that don't belong to
+// its configuration.
+var plainActionFields = []string{"actionType", "targetUri", "body"}
+
+// MarshalJSON encodes the action with the fields of its configuration. The
+// configuration must be for the action type of the action.
+func (a Action) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(plainAction(a))
+	if err != nil || a.Config == nil {
+		return b, err
+	}
+
+	if t := a.Config.ActionType(); t != a.ActionType {
+		return nil, fmt.Errorf("%s action has configuration for %s", a.ActionType, t)
+	}
+
+	cb, err := json.Marshal(a.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(cb, &fields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes the action, along with the configuration for its
+// action type, if any. Fields that belong to neither are ignored, so that
+// actions returned by newer versions of the API can still be decoded. Use
+// UnknownFields to find them.
+func (a *Action) UnmarshalJSON(b []byte) error {
+	var p plainAction
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*a = Action(p)
+
+	cfg := newActionConfig(a.ActionType)
+	if cfg == nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	for _, k := range plainActionFields {
+		delete(fields, k)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cb, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(cb, cfg); err != nil {
+		return fmt.Errorf("invalid %s configuration: %s", a.ActionType, err)
+	}
+
+	known := jsonFieldNames(cfg)
+	for k := range fields {
+		if !containsFold(known, k) {
+			a.unknown = append(a.unknown, k)
+		}
+	}
+	sort.Strings(a.unknown)
+
+	if !reflect.ValueOf(cfg).Elem().IsZero() {
+		a.Config = cfg
+	}
+
+	return nil
+}
+
+// UnknownFields returns the fields of a decoded action that belong to neither
+// the action nor the configuration of its action type, such as misspelled
+// fields in a definition file.
+func (a *Action) UnknownFields() []string {
+	return a.unknown
+}
+
+// jsonFieldNames returns the names of the JSON fields of the struct v points
+// to.
+func jsonFieldNames(v interface{}) []string {
+	var names []string
+
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// containsFold reports whether s contains v, ignoring case, as
+// encoding/json does when matching fields.
+func containsFold(s []string, v string) bool {
+	for _, x := range s {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the action has the fields required by its type.
+func (a *Action) Validate() error {
+	if a.ActionType == "" {
+		return errors.New("missing action type")
+	}
+
+	cfg := a.Config
+	if cfg == nil {
+		cfg = newActionConfig(a.ActionType)
+	}
+	if cfg == nil {
+		return fmt.Errorf("unknown action type %q", a.ActionType)
+	}
+	if cfg.ActionType() != a.ActionType {
+		return fmt.Errorf("%s action has configuration for %s", a.ActionType, cfg.ActionType())
+	}
+
+	return cfg.validate(a)
+}
+
+func (c *HTTPConfig) validate(a *Action) error {
+	if err := validateTargetURI(a, "http", "https"); err != nil {
+		return err
+	}
+
+	for k := range c.Headers {
+		if k == "" || strings.ContainsAny(k, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", k)
+		}
+	}
+
+	return nil
+}
+
+func (c *SlackConfig) validate(a *Action) error {
+	if err := validateTargetURI(a, "https"); err != nil {
+		return err
+	}
+
+	if a.Body == "" && len(c.Blocks) == 0 {
+		return fmt.Errorf("%s requires a body or blocks", a.ActionType)
+	}
+
+	for i, b := range c.Blocks {
+		var block struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(b, &block); err != nil {
+			return fmt.Errorf("invalid block %d: %s", i, err)
+		}
+		if block.Type == "" {
+			return fmt.Errorf("block %d is missing type", i)
+		}
+	}
+
+	return nil
+}
+
+func (c *IFTTTConfig) validate(a *Action) error {
+	return validateTargetURI(a, "https")
+}
+
+func (c *ZapierConfig) validate(a *Action) error {
+	return validateTargetURI(a, "https")
+}
+
+func (c *EmailConfig) validate(a *Action) error {
+	if a.TargetURI != "" {
+		return fmt.Errorf("%s doesn't use a target URI", a.ActionType)
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("%s requires at least one recipient", a.ActionType)
+	}
+	for _, addr := range append(append([]string{}, c.To...), c.Cc...) {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid email address %q", addr)
+		}
+	}
+	if c.Subject == "" {
+		return fmt.Errorf("%s requires a subject", a.ActionType)
+	}
+	if a.Body == "" {
+		return fmt.Errorf("%s requires a body", a.ActionType)
+	}
+
+	return nil
+}
+
+func validateTargetURI(a *Action, schemes ...string) error {
+	if a.TargetURI == "" {
+		return fmt.Errorf("%s requires a target URI", a.ActionType)
+	}
+
+	u, err := url.Parse(a.TargetURI)
+	if err != nil {
+		return fmt.Errorf("invalid target URI: %s", err)
+	}
+	if u.Host == "" || !containsString(schemes, u.Scheme) {
+		return fmt.Errorf("invalid target URI %q: must be an absolute %s URL", a.TargetURI, strings.Join(schemes, " or "))
+	}
+
+	return nil
+}
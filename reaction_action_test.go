@@ -0,0 +1,166 @@
+package serialized
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestActionJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		action *Action
+		json   string
+	}{
+		{
+			name:   "without config",
+			action: &Action{ActionType: ActionTypeHTTPPost, TargetURI: "https://example.com"},
+			json:   `{"actionType":"HTTP_POST","targetUri":"https://example.com"}`,
+		},
+		{
+			name: "http",
+			action: &Action{
+				ActionType: ActionTypeHTTPPost,
+				TargetURI:  "https://example.com",
+				Config:     &HTTPConfig{Headers: map[string]string{"X-Tenant": "acme"}},
+			},
+			json: `{"actionType":"HTTP_POST","headers":{"X-Tenant":"acme"},"targetUri":"https://example.com"}`,
+		},
+		{
+			name: "slack",
+			action: &Action{
+				ActionType: ActionTypeSlackPost,
+				TargetURI:  "https://hooks.slack.com/services/T0/B0/X",
+				Config: &SlackConfig{
+					Blocks: []json.RawMessage{json.RawMessage(`{"type":"divider"}`)},
+				},
+			},
+			json: `{"actionType":"SLACK_POST","blocks":[{"type":"divider"}],"targetUri":"https://hooks.slack.com/services/T0/B0/X"}`,
+		},
+		{
+			name: "email",
+			action: &Action{
+				ActionType: ActionTypeEmail,
+				Body:       "Order ${aggregateId} was placed",
+				Config: &EmailConfig{
+					To:      []string{"orders@example.com"},
+					Subject: "New order",
+				},
+			},
+			json: `{"actionType":"EMAIL","body":"Order ${aggregateId} was placed","subject":"New order","to":["orders@example.com"]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqualJSON(t, b, []byte(tt.json))
+
+			var got Action
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&got, tt.action) {
+				t.Fatalf("got = %#v; want = %#v", got, tt.action)
+			}
+		})
+	}
+}
+
+func TestActionJSONErrors(t *testing.T) {
+	a := Action{
+		ActionType: ActionTypeSlackPost,
+		TargetURI:  "https://hooks.slack.com/services/T0/B0/X",
+		Config:     &HTTPConfig{SigningSecret: "s3cr3t"},
+	}
+	if _, err := json.Marshal(a); err == nil {
+		t.Error("expected error for configuration of another action type")
+	}
+
+	var got Action
+	if err := json.Unmarshal([]byte(`{"actionType":"EMAIL","to":"orders@example.com"}`), &got); err == nil {
+		t.Error("expected error for invalid configuration")
+	}
+}
+
+func TestActionJSONUnknownFields(t *testing.T) {
+	for _, tt := range []struct {
+		json string
+		want []string
+	}{
+		{json: `{"actionType":"HTTP_POST","targetUri":"https://example.com","Headers":{"X-Tenant":"acme"}}`},
+		{json: `{"actionType":"HTTP_POST","targetUri":"https://example.com","channel":"#orders"}`, want: []string{"channel"}},
+		{json: `{"actionType":"EMAIL","to":["orders@example.com"],"subjcet":"New order","retries":3}`, want: []string{"retries", "subjcet"}},
+		{json: `{"actionType":"UNKNOWN_POST","targetUri":"https://example.com","channel":"#orders"}`},
+	} {
+		var got Action
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("%s: unexpected error = %v", tt.json, err)
+		}
+		if !reflect.DeepEqual(got.UnknownFields(), tt.want) {
+			t.Errorf("%s: unknown fields = %v; want = %v", tt.json, got.UnknownFields(), tt.want)
+		}
+	}
+}
+
+func TestActionValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		action  *Action
+		wantErr bool
+	}{
+		{
+			name:   "http",
+			action: &Action{ActionType: ActionTypeHTTPPost, TargetURI: "http://localhost:8080/hooks"},
+		},
+		{
+			name:    "http with invalid header",
+			action:  &Action{ActionType: ActionTypeHTTPPost, TargetURI: "https://example.com", Config: &HTTPConfig{Headers: map[string]string{"X Tenant": "acme"}}},
+			wantErr: true,
+		},
+		{
+			name:    "mismatched config",
+			action:  &Action{ActionType: ActionTypeHTTPPost, TargetURI: "https://example.com", Config: &SlackConfig{}},
+			wantErr: true,
+		},
+		{
+			name:   "slack with blocks",
+			action: &Action{ActionType: ActionTypeSlackPost, TargetURI: "https://hooks.slack.com/x", Config: &SlackConfig{Blocks: []json.RawMessage{json.RawMessage(`{"type":"divider"}`)}}},
+		},
+		{
+			name:    "slack with invalid block",
+			action:  &Action{ActionType: ActionTypeSlackPost, TargetURI: "https://hooks.slack.com/x", Config: &SlackConfig{Blocks: []json.RawMessage{json.RawMessage(`{}`)}}},
+			wantErr: true,
+		},
+		{
+			name:   "ifttt",
+			action: &Action{ActionType: ActionTypeIFTTTPost, TargetURI: "https://maker.ifttt.com/trigger/order/with/key/abc"},
+		},
+		{
+			name:    "zapier over http",
+			action:  &Action{ActionType: ActionTypeZapierPost, TargetURI: "http://hooks.zapier.com/hooks/catch/1/a"},
+			wantErr: true,
+		},
+		{
+			name:   "email",
+			action: &Action{ActionType: ActionTypeEmail, Body: "Hello", Config: &EmailConfig{To: []string{"a@example.com"}, Subject: "Hi"}},
+		},
+		{
+			name:    "email without recipients",
+			action:  &Action{ActionType: ActionTypeEmail, Body: "Hello", Config: &EmailConfig{Subject: "Hi"}},
+			wantErr: true,
+		},
+		{
+			name:    "email with invalid address",
+			action:  &Action{ActionType: ActionTypeEmail, Body: "Hello", Config: &EmailConfig{To: []string{"not an address"}, Subject: "Hi"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// ManualClock is a Clock that only moves when told to. It's useful for
+// testing time-dependent behavior deterministically.
+type ManualClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewManualClock returns a new ManualClock set to t.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{t: t}
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Set sets the current time of the clock.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// ReactionPayload is the body posted by a HTTP_POST action that doesn't
+// define a body of its own.
+type ReactionPayload struct {
+	ReactionName string `json:"reactionName"`
+	AggregateID  string `json:"aggregateId"`
+	Event        *Event `json:"event"`
+}
+
+// ScheduledReaction is a reaction waiting to be triggered.
+type ScheduledReaction struct {
+	Definition  *ReactionDefinition
+	AggregateID string
+	Event       *Event
+	TriggerAt   time.Time
+}
+
+// TriggeredReaction is a reaction whose action has been executed.
+type TriggeredReaction struct {
+	*ScheduledReaction
+
+	StatusCode int
+	Err        error
+}
+
+// ReactionEmulator schedules and triggers reactions locally, without
+// deploying the reaction definitions to Serialized.io. Together with a
+// ManualClock, it lets you test how reaction definitions behave.
+//
+//   clock := serialized.NewManualClock(time.Now())
+//   em := serialized.NewReactionEmulator(defs, serialized.WithEmulatorClock(clock))
+//
+//   em.Process("order", entries...)
+//   clock.Advance(time.Hour)
+//   triggered := em.Trigger(ctx)
+type ReactionEmulator struct {
+	defs       []*ReactionDefinition
+	clock      Clock
+	httpClient *http.Client
+
+	// since is the sequence number Run starts after, or -1 to start at the
+	// head of each feed.
+	since int64
+
+	mu      sync.Mutex
+	pending []*ScheduledReaction
+}
+
+// NewReactionEmulator returns a new ReactionEmulator for the given
+// definitions.
+func NewReactionEmulator(defs []*ReactionDefinition, opts ...func(*ReactionEmulator)) *ReactionEmulator {
+	e := &ReactionEmulator{
+		defs:       defs,
+		clock:      systemClock{},
+		httpClient: &http.Client{},
+		since:      -1,
+	}
+
+	for _, f := range opts {
+		f(e)
+	}
+
+	return e
+}
+
+// WithEmulatorClock sets the clock used to decide when reactions trigger.
+func WithEmulatorClock(c Clock) func(*ReactionEmulator) {
+	return func(e *ReactionEmulator) {
+		e.clock = c
+	}
+}
+
+// WithEmulatorHTTPClient sets the HTTP client used to execute actions.
+func WithEmulatorHTTPClient(c *http.Client) func(*ReactionEmulator) {
+	return func(e *ReactionEmulator) {
+		e.httpClient = c
+	}
+}
+
+// WithEmulatorSince makes Run read the feeds from after the given sequence
+// number, rather than from the head of each feed. Use 0 to replay the whole
+// feeds. Note that reactions to past events trigger as soon as they are
+// due, which for the system clock is right away.
+func WithEmulatorSince(seq int64) func(*ReactionEmulator) {
+	return func(e *ReactionEmulator) {
+		e.since = seq
+	}
+}
+
+// Process schedules reactions for the events in the given entries of a
+// feed, and cancels pending reactions for aggregates that receive a cancel
+// event. Only the reaction definitions for the feed are considered. If the
+// trigger time of a reaction can't be worked out, an error is returned and
+// none of the entries are processed.
+func (e *ReactionEmulator) Process(feed string, entries ...*FeedEntry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pending := make([]*ScheduledReaction, len(e.pending))
+	copy(pending, e.pending)
+
+	for _, entry := range entries {
+		for _, ev := range entry.Events {
+			for _, def := range e.defs {
+				if def.Feed != feed {
+					continue
+				}
+
+				if containsString(def.CancelOnEventTypes, ev.Type) {
+					pending = cancelScheduledReactions(pending, def, entry.AggregateID)
+				}
+
+				if def.ReactOnEventType != ev.Type {
+					continue
+				}
+
+				at, err := reactionTriggerTime(def, entry, ev, e.clock.Now())
+				if err != nil {
+					return fmt.Errorf("reaction %q: %s", def.Name, err)
+				}
+
+				pending = append(pending, &ScheduledReaction{
+					Definition:  def,
+					AggregateID: entry.AggregateID,
+					Event:       ev,
+					TriggerAt:   at,
+				})
+			}
+		}
+	}
+
+	e.pending = pending
+
+	return nil
+}
+
+// cancelScheduledReactions returns the reactions that aren't for the given
+// definition and aggregate.
+func cancelScheduledReactions(pending []*ScheduledReaction, def *ReactionDefinition, aggID string) []*ScheduledReaction {
+	var res []*ScheduledReaction
+	for _, r := range pending {
+		if r.Definition == def && r.AggregateID == aggID {
+			continue
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// reactionTriggerTime returns the time a reaction triggered by the given
+// event should execute. Unless the definition has a trigger time field, the
+// time of the feed entry is used, or now if the entry has no timestamp.
+func reactionTriggerTime(def *ReactionDefinition, entry *FeedEntry, ev *Event, now time.Time) (time.Time, error) {
+	at := now
+	if entry.Timestamp > 0 {
+		at = time.Unix(0, entry.Timestamp*int64(time.Millisecond))
+	}
+
+	if def.TriggerTimeField != "" {
+		t, err := lookupTime(ev.Data, def.TriggerTimeField)
+		if err != nil {
+			return time.Time{}, err
+		}
+		at = t
+	}
+
+	if def.Offset != "" {
+		d, err := ParseDuration(def.Offset)
+		if err != nil {
+			return time.Time{}, err
+		}
+		at = at.Add(d.Duration())
+	}
+
+	return at, nil
+}
+
+// Pending returns the reactions that are scheduled but not yet triggered,
+// ordered by trigger time.
+func (e *ReactionEmulator) Pending() []*ScheduledReaction {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pending := make([]*ScheduledReaction, len(e.pending))
+	copy(pending, e.pending)
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].TriggerAt.Before(pending[j].TriggerAt)
+	})
+
+	return pending
+}
+
+// Trigger executes the actions of all pending reactions that are due
+// according to the clock, and returns them in the order they were
+// triggered.
+func (e *ReactionEmulator) Trigger(ctx context.Context) []*TriggeredReaction {
+	now := e.clock.Now()
+
+	e.mu.Lock()
+	var due, pending []*ScheduledReaction
+	for _, r := range e.pending {
+		if r.TriggerAt.After(now) {
+			pending = append(pending, r)
+		} else {
+			due = append(due, r)
+		}
+	}
+	e.pending = pending
+	e.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].TriggerAt.Before(due[j].TriggerAt)
+	})
+
+	var triggered []*TriggeredReaction
+	for _, r := range due {
+		status, err := e.execute(ctx, r)
+		triggered = append(triggered, &TriggeredReaction{
+			ScheduledReaction: r,
+			StatusCode:        status,
+			Err:               err,
+		})
+	}
+
+	return triggered
+}
+
+// Run follows the feeds of the reaction definitions and triggers reactions
+// as they become due. Only events stored after Run starts are processed,
+// unless WithEmulatorSince is used. This call blocks until the provided
+// context is cancelled.
+func (e *ReactionEmulator) Run(ctx context.Context, c *Client, fn func(*TriggeredReaction)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	feeds := make(map[string]bool)
+	for _, def := range e.defs {
+		feeds[def.Feed] = true
+	}
+
+	since := make(map[string]int64)
+	for feed := range feeds {
+		seq := e.since
+		if seq < 0 {
+			head, err := c.FeedSequenceNumber(ctx, feed)
+			if err != nil {
+				return err
+			}
+			seq = head
+		}
+		since[feed] = seq
+	}
+
+	errc := make(chan error, len(feeds)+1)
+
+	for feed := range feeds {
+		go func(feed string) {
+			errc <- c.Feed(ctx, feed, since[feed], func(entry *FeedEntry) {
+				if err := e.Process(feed, entry); err != nil {
+					select {
+					case errc <- err:
+					default:
+					}
+					cancel()
+				}
+			})
+		}(feed)
+	}
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-time.After(c.pollInterval):
+			for _, r := range e.Trigger(ctx) {
+				if fn != nil {
+					fn(r)
+				}
+			}
+		}
+	}
+}
+
+func (e *ReactionEmulator) execute(ctx context.Context, r *ScheduledReaction) (int, error) {
+	req, err := PreviewReaction(r.Definition, r.AggregateID, r.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := e.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// lookupTime returns the time found at the dot-separated path in the given
+// JSON data. The value can either be a number of milliseconds since epoch,
+// or a RFC 3339 formatted string.
+func lookupTime(data json.RawMessage, path string) (time.Time, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return time.Time{}, fmt.Errorf("trigger time field %q not found", path)
+		}
+		if v, ok = m[key]; !ok {
+			return time.Time{}, fmt.Errorf("trigger time field %q not found", path)
+		}
+	}
+
+	switch v := v.(type) {
+	case float64:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Parse("2006-01-02", v)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("trigger time field %q is not a time", path)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
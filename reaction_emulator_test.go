@@ -0,0 +1,281 @@
+package serialized
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReactionEmulator(t *testing.T) {
+	var received []ReactionPayload
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p ReactionPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, p)
+	}))
+
+	def := &ReactionDefinition{
+		Name:               "payment-reminder",
+		Feed:               "order",
+		ReactOnEventType:   "OrderPlaced",
+		CancelOnEventTypes: []string{"OrderPaid"},
+		Offset:             "PT1H",
+		Action: &Action{
+			ActionType: ActionTypeHTTPPost,
+			TargetURI:  ts.URL,
+		},
+	}
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	em := NewReactionEmulator([]*ReactionDefinition{def}, WithEmulatorClock(clock))
+
+	err := em.Process("order",
+		&FeedEntry{AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		&FeedEntry{AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}},
+		&FeedEntry{AggregateID: "a", Events: []*Event{{ID: "3", Type: "OrderPaid"}}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := em.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("unexpected number of pending reactions = %d; want = %d", len(pending), 1)
+	}
+	if want := start.Add(time.Hour); !pending[0].TriggerAt.Equal(want) {
+		t.Fatalf("unexpected trigger time = %s; want = %s", pending[0].TriggerAt, want)
+	}
+
+	clock.Advance(59 * time.Minute)
+
+	if triggered := em.Trigger(context.Background()); len(triggered) != 0 {
+		t.Fatalf("unexpected number of triggered reactions = %d; want = %d", len(triggered), 0)
+	}
+
+	clock.Advance(time.Minute)
+
+	triggered := em.Trigger(context.Background())
+	if len(triggered) != 1 {
+		t.Fatalf("unexpected number of triggered reactions = %d; want = %d", len(triggered), 1)
+	}
+	if triggered[0].Err != nil {
+		t.Fatal(triggered[0].Err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("unexpected number of requests = %d; want = %d", len(received), 1)
+	}
+	if received[0].AggregateID != "b" {
+		t.Errorf("unexpected aggregate id = %s; want = %s", received[0].AggregateID, "b")
+	}
+	if len(em.Pending()) != 0 {
+		t.Errorf("reaction is still pending")
+	}
+}
+
+func TestReactionEmulatorFeeds(t *testing.T) {
+	orderDef := &ReactionDefinition{
+		Name:               "order-reminder",
+		Feed:               "order",
+		ReactOnEventType:   "Created",
+		CancelOnEventTypes: []string{"Closed"},
+	}
+	paymentDef := &ReactionDefinition{
+		Name:               "payment-reminder",
+		Feed:               "payment",
+		ReactOnEventType:   "Created",
+		CancelOnEventTypes: []string{"Closed"},
+	}
+
+	em := NewReactionEmulator([]*ReactionDefinition{orderDef, paymentDef})
+
+	if err := em.Process("order", &FeedEntry{AggregateID: "a", Events: []*Event{{ID: "1", Type: "Created"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := em.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("unexpected number of pending reactions = %d; want = %d", len(pending), 1)
+	}
+	if pending[0].Definition != orderDef {
+		t.Fatalf("unexpected reaction = %s; want = %s", pending[0].Definition.Name, orderDef.Name)
+	}
+
+	// A cancel event in another feed leaves the reaction pending.
+	if err := em.Process("payment", &FeedEntry{AggregateID: "a", Events: []*Event{{ID: "2", Type: "Closed"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(em.Pending()) != 1 {
+		t.Fatalf("reaction was cancelled by an event in another feed")
+	}
+
+	if err := em.Process("order", &FeedEntry{AggregateID: "a", Events: []*Event{{ID: "3", Type: "Closed"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(em.Pending()) != 0 {
+		t.Fatalf("reaction wasn't cancelled")
+	}
+}
+
+func TestReactionEmulatorProcessError(t *testing.T) {
+	def := &ReactionDefinition{
+		Name:               "shipping-notice",
+		Feed:               "order",
+		ReactOnEventType:   "OrderShipped",
+		CancelOnEventTypes: []string{"OrderCancelled"},
+		TriggerTimeField:   "expectedAt",
+	}
+
+	em := NewReactionEmulator([]*ReactionDefinition{def})
+
+	err := em.Process("order", &FeedEntry{
+		AggregateID: "a",
+		Events:      []*Event{{ID: "1", Type: "OrderShipped", Data: json.RawMessage(`{"expectedAt":"2018-01-05T10:00:00Z"}`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = em.Process("order",
+		&FeedEntry{AggregateID: "a", Events: []*Event{{ID: "2", Type: "OrderCancelled"}}},
+		&FeedEntry{AggregateID: "b", Events: []*Event{{ID: "3", Type: "OrderShipped", Data: json.RawMessage(`{"expectedAt":"2018-01-05T10:00:00Z"}`)}}},
+		&FeedEntry{AggregateID: "c", Events: []*Event{{ID: "4", Type: "OrderShipped", Data: json.RawMessage(`{}`)}}},
+	)
+	if err == nil {
+		t.Fatal("expected an error for the missing trigger time field")
+	}
+
+	pending := em.Pending()
+	if len(pending) != 1 || pending[0].AggregateID != "a" {
+		t.Fatalf("pending reactions changed by a failed batch")
+	}
+}
+
+func TestReactionEmulatorTriggerTimeField(t *testing.T) {
+	var body string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = string(b)
+	}))
+
+	def := &ReactionDefinition{
+		Name:             "shipping-notice",
+		Feed:             "order",
+		ReactOnEventType: "OrderShipped",
+		TriggerTimeField: "delivery.expectedAt",
+		Offset:           "-P1D",
+		Action: &Action{
+			ActionType: ActionTypeSlackPost,
+			TargetURI:  ts.URL,
+			Body:       "Order arrives tomorrow",
+		},
+	}
+
+	clock := NewManualClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	em := NewReactionEmulator([]*ReactionDefinition{def}, WithEmulatorClock(clock))
+
+	err := em.Process("order", &FeedEntry{
+		AggregateID: "a",
+		Events: []*Event{
+			{
+				ID:   "1",
+				Type: "OrderShipped",
+				Data: json.RawMessage(`{"delivery":{"expectedAt":"2018-01-05T10:00:00Z"}}`),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Set(time.Date(2018, 1, 4, 10, 0, 0, 0, time.UTC))
+
+	triggered := em.Trigger(context.Background())
+	if len(triggered) != 1 {
+		t.Fatalf("unexpected number of triggered reactions = %d; want = %d", len(triggered), 1)
+	}
+	if want := `{"text":"Order arrives tomorrow"}`; body != want {
+		t.Errorf("unexpected body = %s; want = %s", body, want)
+	}
+}
+
+func TestReactionEmulatorRunSince(t *testing.T) {
+	var tests = []struct {
+		name  string
+		opts  []func(*ReactionEmulator)
+		since string
+	}{
+		{name: "head", since: "10"},
+		{name: "replay", opts: []func(*ReactionEmulator){WithEmulatorSince(0)}, since: ""},
+		{name: "since", opts: []func(*ReactionEmulator){WithEmulatorSince(5)}, since: "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reads := make(chan string, 1)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "HEAD" && r.URL.Path == "/feeds/order":
+					w.Header().Set("Serialized-Sequencenumber-Current", "10")
+				case r.Method == "GET" && r.URL.Path == "/feeds/order":
+					select {
+					case reads <- r.URL.Query().Get("since"):
+					default:
+					}
+					w.Write([]byte(`{"entries":[]}`))
+				default:
+					t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer ts.Close()
+
+			c := NewClient(
+				WithBaseURL(ts.URL),
+				WithPollInterval(time.Millisecond),
+			)
+
+			def := &ReactionDefinition{
+				Name:             "payment-reminder",
+				Feed:             "order",
+				ReactOnEventType: "OrderPlaced",
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			em := NewReactionEmulator([]*ReactionDefinition{def}, tt.opts...)
+
+			done := make(chan error, 1)
+			go func() { done <- em.Run(ctx, c, nil) }()
+
+			select {
+			case since := <-reads:
+				if since != tt.since {
+					t.Fatalf("since = %q; want = %q", since, tt.since)
+				}
+			case err := <-done:
+				t.Fatal(err)
+			case <-time.After(time.Second):
+				t.Fatal("feed wasn't read")
+			}
+
+			cancel()
+			<-done
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package serialized
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// SignatureHeader is the HTTP header holding the signature of a reaction
+// delivery.
+const SignatureHeader = "Serialized-Signature"
+
+// defaultMaxDeliveries is the number of delivered event IDs remembered by a
+// ReactionHandler for deduplication.
+const defaultMaxDeliveries = 10000
+
+// defaultMaxBodySize is the max size in bytes of a reaction delivery.
+const defaultMaxBodySize = 1 << 20
+
+// Sign returns the hex-encoded HMAC-SHA256 signature of body using the given
+// secret.
+func Sign(secret, body []byte) string {
+	return hex.EncodeToString(sign(secret, body))
+}
+
+func sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// ReactionHandler is a http.Handler that receives reactions triggered by
+// HTTP_POST actions, and dispatches them to functions registered for the
+// type of the event that triggered the reaction.
+//
+// Repeated deliveries of a reaction that has already been handled
+// successfully are acknowledged without running the function again.
+type ReactionHandler struct {
+	secret        []byte
+	maxDeliveries int
+	maxBodySize   int64
+	errorLog      *log.Logger
+
+	mu        sync.Mutex
+	handlers  map[string]func(context.Context, *ReactionPayload) error
+	delivered map[string]bool
+	order     []string
+}
+
+// NewReactionHandler returns a new ReactionHandler.
+func NewReactionHandler(opts ...func(*ReactionHandler)) *ReactionHandler {
+	h := &ReactionHandler{
+		maxDeliveries: defaultMaxDeliveries,
+		maxBodySize:   defaultMaxBodySize,
+		handlers:      make(map[string]func(context.Context, *ReactionPayload) error),
+		delivered:     make(map[string]bool),
+	}
+
+	for _, f := range opts {
+		f(h)
+	}
+
+	return h
+}
+
+// WithSigningSecret makes the handler reject deliveries that aren't signed
+// with the given secret.
+func WithSigningSecret(secret string) func(*ReactionHandler) {
+	return func(h *ReactionHandler) {
+		h.secret = []byte(secret)
+	}
+}
+
+// WithMaxDeliveries sets the number of delivered event IDs to remember for
+// deduplication.
+func WithMaxDeliveries(n int) func(*ReactionHandler) {
+	return func(h *ReactionHandler) {
+		h.maxDeliveries = n
+	}
+}
+
+// WithMaxBodySize sets the max size in bytes of a reaction delivery. Larger
+// deliveries are rejected before their signature is checked.
+func WithMaxBodySize(n int64) func(*ReactionHandler) {
+	return func(h *ReactionHandler) {
+		h.maxBodySize = n
+	}
+}
+
+// WithErrorLog sets the logger for errors returned by the registered
+// functions. By default, the standard logger is used.
+func WithErrorLog(l *log.Logger) func(*ReactionHandler) {
+	return func(h *ReactionHandler) {
+		h.errorLog = l
+	}
+}
+
+// HandleFunc registers the function to run for reactions triggered by events
+// of the given type.
+func (h *ReactionHandler) HandleFunc(eventType string, fn func(context.Context, *ReactionPayload) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers[eventType] = fn
+}
+
+// HandleReaction registers a function to run for reactions triggered by
+// events of the given type, with the event data decoded into T.
+func HandleReaction[T any](h *ReactionHandler, eventType string, fn func(ctx context.Context, aggID string, data T) error) {
+	h.HandleFunc(eventType, func(ctx context.Context, p *ReactionPayload) error {
+		var data T
+		if len(p.Event.Data) > 0 {
+			if err := json.Unmarshal(p.Event.Data, &data); err != nil {
+				return &reactionDecodeError{err: err}
+			}
+		}
+		return fn(ctx, p.AggregateID, data)
+	})
+}
+
+type reactionDecodeError struct {
+	err error
+}
+
+func (e *reactionDecodeError) Error() string {
+	return fmt.Sprintf("unable to decode event data: %s", e.err)
+}
+
+// ServeHTTP handles a reaction delivery.
+func (h *ReactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	if len(h.secret) > 0 {
+		sig, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+		if err != nil || !hmac.Equal(sig, sign(h.secret, body)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var p ReactionPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "malformed payload", http.StatusBadRequest)
+		return
+	}
+	if p.Event == nil || p.Event.ID == "" || p.Event.Type == "" {
+		http.Error(w, "payload is missing event", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	fn, ok := h.handlers[p.Event.Type]
+	if !ok {
+		h.mu.Unlock()
+		http.Error(w, fmt.Sprintf("no handler for event type %q", p.Event.Type), http.StatusNotFound)
+		return
+	}
+
+	// The same event may trigger several reactions.
+	key := p.ReactionName + "/" + p.Event.ID
+
+	if done, seen := h.delivered[key]; seen {
+		h.mu.Unlock()
+		if !done {
+			http.Error(w, "delivery is already being handled", http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	// Claim the delivery to avoid handling concurrent deliveries twice.
+	h.delivered[key] = false
+	h.mu.Unlock()
+
+	if err := fn(r.Context(), &p); err != nil {
+		h.mu.Lock()
+		delete(h.delivered, key)
+		h.mu.Unlock()
+
+		if _, ok := err.(*reactionDecodeError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The error may reveal details about the receiver, so it's logged
+		// rather than returned.
+		h.logf("reaction %q for event %s: %s", p.ReactionName, p.Event.ID, err)
+		http.Error(w, "unable to handle reaction", http.StatusInternalServerError)
+		return
+	}
+
+	h.mu.Lock()
+	h.delivered[key] = true
+	h.order = append(h.order, key)
+	for len(h.order) > h.maxDeliveries {
+		delete(h.delivered, h.order[0])
+		h.order = h.order[1:]
+	}
+	h.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ReactionHandler) logf(format string, args ...interface{}) {
+	if h.errorLog != nil {
+		h.errorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
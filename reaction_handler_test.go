@@ -0,0 +1,115 @@
+package serialized
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReactionHandler(t *testing.T) {
+	var (
+		calls int
+		fail  = true
+	)
+
+	var logged bytes.Buffer
+
+	h := NewReactionHandler(WithSigningSecret("s3cr3t"), WithErrorLog(log.New(&logged, "", 0)))
+
+	HandleReaction(h, "PaymentProcessed", func(ctx context.Context, aggID string, pp testPaymentProcessed) error {
+		calls++
+		if aggID != "a" {
+			t.Errorf("unexpected aggregate id = %s; want = %s", aggID, "a")
+		}
+		if pp.Amount != 1000 {
+			t.Errorf("unexpected amount = %d; want = %d", pp.Amount, 1000)
+		}
+		if fail {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	payload := []byte(`{"reactionName":"r","aggregateId":"a","event":{"eventId":"1","eventType":"PaymentProcessed","data":{"amount":1000}}}`)
+
+	var rec *httptest.ResponseRecorder
+
+	deliver := func(body []byte, sig string) int {
+		req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		req.Header.Set(SignatureHeader, sig)
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	if got := deliver(payload, Sign([]byte("wrong"), payload)); got != http.StatusUnauthorized {
+		t.Errorf("unexpected status for invalid signature = %d; want = %d", got, http.StatusUnauthorized)
+	}
+
+	sig := Sign([]byte("s3cr3t"), payload)
+
+	if got := deliver(payload, sig); got != http.StatusInternalServerError {
+		t.Errorf("unexpected status for failed delivery = %d; want = %d", got, http.StatusInternalServerError)
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("temporary failure")) {
+		t.Errorf("error returned to caller = %s", rec.Body.String())
+	}
+	if !bytes.Contains(logged.Bytes(), []byte("temporary failure")) {
+		t.Errorf("error wasn't logged")
+	}
+
+	fail = false
+
+	if got := deliver(payload, sig); got != http.StatusOK {
+		t.Errorf("unexpected status for retried delivery = %d; want = %d", got, http.StatusOK)
+	}
+	if got := deliver(payload, sig); got != http.StatusOK {
+		t.Errorf("unexpected status for repeated delivery = %d; want = %d", got, http.StatusOK)
+	}
+	if calls != 2 {
+		t.Errorf("unexpected number of calls = %d; want = %d", calls, 2)
+	}
+
+	unknown := []byte(`{"aggregateId":"a","event":{"eventId":"2","eventType":"OrderPlaced"}}`)
+	if got := deliver(unknown, Sign([]byte("s3cr3t"), unknown)); got != http.StatusNotFound {
+		t.Errorf("unexpected status for unknown event type = %d; want = %d", got, http.StatusNotFound)
+	}
+
+	malformed := []byte(`{"aggregateId":"a","event":{"eventId":"3","eventType":"PaymentProcessed","data":{"amount":"lots"}}}`)
+	if got := deliver(malformed, Sign([]byte("s3cr3t"), malformed)); got != http.StatusBadRequest {
+		t.Errorf("unexpected status for malformed data = %d; want = %d", got, http.StatusBadRequest)
+	}
+}
+
+func TestReactionHandlerMethodNotAllowed(t *testing.T) {
+	h := NewReactionHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status = %d; want = %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestReactionHandlerMaxBodySize(t *testing.T) {
+	h := NewReactionHandler(WithSigningSecret("s3cr3t"), WithMaxBodySize(10))
+
+	body := bytes.Repeat([]byte("a"), 11)
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, Sign([]byte("s3cr3t"), body))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("unexpected status = %d; want = %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,339 @@
+package serialized
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReactionFunc is called by a ReactionRunner when a reaction triggers.
+type ReactionFunc func(ctx context.Context, t *Trigger) error
+
+// ReactionRunner runs reactions locally, as an alternative to deploying the
+// reaction definitions to Serialized.io. It follows the feeds of the
+// registered definitions, and keeps pending triggers along with its position
+// in each feed in a TriggerStore, so that it can be restarted.
+//
+// A trigger is marked as completed before its reaction is executed, which
+// means a reaction never fires twice, but may not fire at all if the process
+// stops while it is executing.
+type ReactionRunner struct {
+	client     *Client
+	store      TriggerStore
+	clock      Clock
+	httpClient *http.Client
+
+	// since is the sequence number Run starts after for feeds without a
+	// checkpoint, or -1 to start at the head of the feed.
+	since int64
+
+	mu        sync.Mutex
+	reactions map[string]*registeredReaction
+}
+
+type registeredReaction struct {
+	def *ReactionDefinition
+	fn  ReactionFunc
+}
+
+// NewReactionRunner returns a new ReactionRunner that follows feeds using
+// the given client, and keeps its state in the given store.
+func NewReactionRunner(c *Client, store TriggerStore, opts ...func(*ReactionRunner)) *ReactionRunner {
+	r := &ReactionRunner{
+		client:     c,
+		store:      store,
+		clock:      systemClock{},
+		httpClient: &http.Client{},
+		since:      -1,
+		reactions:  make(map[string]*registeredReaction),
+	}
+
+	for _, f := range opts {
+		f(r)
+	}
+
+	return r
+}
+
+// WithRunnerClock sets the clock used to decide when reactions trigger.
+func WithRunnerClock(c Clock) func(*ReactionRunner) {
+	return func(r *ReactionRunner) {
+		r.clock = c
+	}
+}
+
+// WithRunnerHTTPClient sets the HTTP client used to execute actions.
+func WithRunnerHTTPClient(c *http.Client) func(*ReactionRunner) {
+	return func(r *ReactionRunner) {
+		r.httpClient = c
+	}
+}
+
+// WithRunnerSince makes Run read feeds without a stored checkpoint from after
+// the given sequence number, rather than from the head of the feed. Use 0 to
+// replay the whole feeds. Note that reactions to past events trigger as soon
+// as they are due, which for the system clock is right away.
+func WithRunnerSince(seq int64) func(*ReactionRunner) {
+	return func(r *ReactionRunner) {
+		r.since = seq
+	}
+}
+
+// Register adds a reaction definition to the runner. When the reaction
+// triggers, fn is called. If fn is nil, the action of the definition is
+// executed instead.
+func (r *ReactionRunner) Register(def *ReactionDefinition, fn ReactionFunc) error {
+	if fn == nil {
+		if err := def.Validate(); err != nil {
+			return fmt.Errorf("invalid reaction %q: %s", def.Name, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.reactions[def.Name]; ok {
+		return fmt.Errorf("reaction %q already registered", def.Name)
+	}
+	r.reactions[def.Name] = &registeredReaction{def: def, fn: fn}
+
+	return nil
+}
+
+// Process schedules and cancels triggers for the events in a feed entry, and
+// stores the sequence number of the entry as the checkpoint for the feed.
+// Entries at or before the checkpoint have already been processed, and are
+// ignored.
+func (r *ReactionRunner) Process(feed string, entry *FeedEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.SequenceNumber > 0 {
+		seq, err := r.store.Checkpoint(feed)
+		if err != nil {
+			return err
+		}
+		if entry.SequenceNumber <= seq {
+			return nil
+		}
+	}
+
+	for _, ev := range entry.Events {
+		for _, rr := range r.reactions {
+			def := rr.def
+			if def.Feed != feed {
+				continue
+			}
+
+			if containsString(def.CancelOnEventTypes, ev.Type) {
+				if err := r.cancel(def.Name, entry.AggregateID); err != nil {
+					return err
+				}
+			}
+
+			if def.ReactOnEventType != ev.Type {
+				continue
+			}
+
+			at, err := reactionTriggerTime(def, entry, ev, r.clock.Now())
+			if err != nil {
+				return fmt.Errorf("reaction %q: %s", def.Name, err)
+			}
+
+			err = r.store.Schedule(&Trigger{
+				ID:           def.Name + "/" + ev.ID,
+				ReactionName: def.Name,
+				Feed:         feed,
+				AggregateID:  entry.AggregateID,
+				Event:        ev,
+				TriggerAt:    at,
+
+				SequenceNumber: entry.SequenceNumber,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if entry.SequenceNumber > 0 {
+		return r.store.SetCheckpoint(feed, entry.SequenceNumber)
+	}
+
+	return nil
+}
+
+func (r *ReactionRunner) cancel(name, aggID string) error {
+	pending, err := r.store.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range pending {
+		if t.ReactionName == name && t.AggregateID == aggID {
+			if _, err := r.store.Complete(t.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Trigger executes all pending triggers that are due according to the
+// clock. The given function, if any, is called with the result of each
+// execution.
+func (r *ReactionRunner) Trigger(ctx context.Context, fn func(*Trigger, error)) error {
+	pending, err := r.store.Pending()
+	if err != nil {
+		return err
+	}
+
+	now := r.clock.Now()
+
+	for _, t := range pending {
+		if t.TriggerAt.After(now) {
+			break
+		}
+
+		r.mu.Lock()
+		rr, ok := r.reactions[t.ReactionName]
+		r.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		// The trigger may have been cancelled since Pending was called.
+		claimed, err := r.store.Complete(t.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = r.execute(ctx, rr, t)
+		if fn != nil {
+			fn(t, err)
+		}
+	}
+
+	return nil
+}
+
+// Run follows the feeds of the registered reactions from their stored
+// checkpoints, and executes triggers as they become due. Feeds without a
+// checkpoint are followed from their current head, unless WithRunnerSince is
+// used. This call blocks until the provided context is cancelled.
+//
+// Stores that write changes in batches, such as FileTriggerStore, are
+// flushed each poll interval, and when Run returns.
+func (r *ReactionRunner) Run(ctx context.Context, fn func(*Trigger, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	feeds := make(map[string]bool)
+	r.mu.Lock()
+	for _, rr := range r.reactions {
+		feeds[rr.def.Feed] = true
+	}
+	r.mu.Unlock()
+
+	errc := make(chan error, len(feeds)+1)
+
+	for feed := range feeds {
+		seq, err := r.store.Checkpoint(feed)
+		if err != nil {
+			return err
+		}
+		if seq == 0 {
+			if seq, err = r.start(ctx, feed); err != nil {
+				return err
+			}
+		}
+
+		go func(feed string, seq int64) {
+			errc <- r.client.Feed(ctx, feed, seq, func(entry *FeedEntry) {
+				if err := r.Process(feed, entry); err != nil {
+					select {
+					case errc <- err:
+					default:
+					}
+					cancel()
+				}
+			})
+		}(feed, seq)
+	}
+
+	for {
+		select {
+		case err := <-errc:
+			if ferr := r.flush(); ferr != nil && err == nil {
+				return ferr
+			}
+			return err
+		case <-time.After(r.client.pollInterval):
+			if err := r.Trigger(ctx, fn); err != nil {
+				return err
+			}
+			if err := r.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// start returns the sequence number to follow a feed without a checkpoint
+// from, and stores it as the checkpoint, so that entries stored while the
+// runner is stopped are processed when it's restarted.
+func (r *ReactionRunner) start(ctx context.Context, feed string) (int64, error) {
+	seq := r.since
+	if seq < 0 {
+		head, err := r.client.FeedSequenceNumber(ctx, feed)
+		if err != nil {
+			return 0, err
+		}
+		seq = head
+	}
+
+	if seq > 0 {
+		if err := r.store.SetCheckpoint(feed, seq); err != nil {
+			return 0, err
+		}
+	}
+
+	return seq, nil
+}
+
+// flush writes the changes of stores that write them in batches.
+func (r *ReactionRunner) flush() error {
+	if f, ok := r.store.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (r *ReactionRunner) execute(ctx context.Context, rr *registeredReaction, t *Trigger) error {
+	if rr.fn != nil {
+		return rr.fn(ctx, t)
+	}
+
+	req, err := PreviewReaction(rr.def, t.AggregateID, t.Event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package serialized
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReactionRunner(t *testing.T) {
+	def := &ReactionDefinition{
+		Name:               "payment-reminder",
+		Feed:               "order",
+		ReactOnEventType:   "OrderPlaced",
+		CancelOnEventTypes: []string{"OrderPaid"},
+		Offset:             "PT1H",
+	}
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	path := filepath.Join(t.TempDir(), "triggers.json")
+
+	var fired []string
+	fn := func(ctx context.Context, tr *Trigger) error {
+		fired = append(fired, tr.AggregateID)
+		return nil
+	}
+
+	newRunner := func() *ReactionRunner {
+		store, err := NewFileTriggerStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewReactionRunner(NewClient(), store, WithRunnerClock(clock))
+		if err := r.Register(def, fn); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	r := newRunner()
+	for _, entry := range []*FeedEntry{
+		{SequenceNumber: 1, AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}},
+		{SequenceNumber: 3, AggregateID: "a", Events: []*Event{{ID: "3", Type: "OrderPaid"}}},
+	} {
+		if err := r.Process("order", entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.Advance(time.Hour)
+	if err := r.Trigger(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fired) != 1 || fired[0] != "b" {
+		t.Fatalf("got = %v; want = %v", fired, []string{"b"})
+	}
+
+	// Restart the runner and replay the feed from the beginning.
+	r = newRunner()
+
+	seq, err := r.store.Checkpoint("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq != 3 {
+		t.Fatalf("got checkpoint = %d; want = %d", seq, 3)
+	}
+
+	if err := r.Process("order", &FeedEntry{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Trigger(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fired) != 1 {
+		t.Fatalf("reaction fired %d times; want = %d", len(fired), 1)
+	}
+}
+
+func TestReactionRunnerRegister(t *testing.T) {
+	r := NewReactionRunner(NewClient(), NewMemoryTriggerStore())
+
+	def := &ReactionDefinition{Name: "a", Feed: "order", ReactOnEventType: "OrderPlaced"}
+	fn := func(ctx context.Context, tr *Trigger) error { return nil }
+
+	if err := r.Register(def, fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(def, fn); err == nil {
+		t.Fatal("expected error when registering reaction twice")
+	}
+	if err := r.Register(&ReactionDefinition{Name: "b", Feed: "order", ReactOnEventType: "OrderPlaced"}, nil); err == nil {
+		t.Fatal("expected error when registering reaction without action")
+	}
+}
+
+// cancellingStore calls a function once, right after the first call to
+// Pending, to cancel triggers while the runner is triggering them.
+type cancellingStore struct {
+	*MemoryTriggerStore
+	afterPending func()
+}
+
+func (s *cancellingStore) Pending() ([]*Trigger, error) {
+	pending, err := s.MemoryTriggerStore.Pending()
+	if f := s.afterPending; f != nil {
+		s.afterPending = nil
+		f()
+	}
+	return pending, err
+}
+
+func TestReactionRunnerTriggerCancelled(t *testing.T) {
+	def := &ReactionDefinition{
+		Name:               "payment-reminder",
+		Feed:               "order",
+		ReactOnEventType:   "OrderPlaced",
+		CancelOnEventTypes: []string{"OrderPaid"},
+	}
+
+	store := &cancellingStore{MemoryTriggerStore: NewMemoryTriggerStore()}
+	r := NewReactionRunner(NewClient(), store)
+
+	var fired []string
+	err := r.Register(def, func(ctx context.Context, tr *Trigger) error {
+		fired = append(fired, tr.AggregateID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range []*FeedEntry{
+		{SequenceNumber: 1, AggregateID: "a", Events: []*Event{{ID: "1", Type: "OrderPlaced"}}},
+		{SequenceNumber: 2, AggregateID: "b", Events: []*Event{{ID: "2", Type: "OrderPlaced"}}},
+	} {
+		if err := r.Process("order", entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancel the trigger for a after Trigger has read the pending triggers,
+	// but before it executes them.
+	store.afterPending = func() {
+		if err := r.Process("order", &FeedEntry{SequenceNumber: 3, AggregateID: "a", Events: []*Event{{ID: "3", Type: "OrderPaid"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Trigger(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fired) != 1 || fired[0] != "b" {
+		t.Fatalf("got = %v; want = %v", fired, []string{"b"})
+	}
+}
+
+func TestReactionRunnerRunStart(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		opts           []func(*ReactionRunner)
+		checkpoint     int64
+		wantSince      string
+		wantCheckpoint int64
+	}{
+		{name: "head", wantSince: "10", wantCheckpoint: 10},
+		{name: "replay", opts: []func(*ReactionRunner){WithRunnerSince(0)}, wantSince: ""},
+		{name: "since", opts: []func(*ReactionRunner){WithRunnerSince(4)}, wantSince: "4", wantCheckpoint: 4},
+		{name: "checkpoint", checkpoint: 7, wantSince: "7", wantCheckpoint: 7},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sincec := make(chan string, 1)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "HEAD" && r.URL.Path == "/feeds/order":
+					w.Header().Set("Serialized-Sequencenumber-Current", "10")
+				case r.Method == "GET" && r.URL.Path == "/feeds/order":
+					select {
+					case sincec <- r.URL.Query().Get("since"):
+					default:
+					}
+					w.Write([]byte(`{"entries":[]}`))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer ts.Close()
+
+			store := NewMemoryTriggerStore()
+			if tt.checkpoint > 0 {
+				if err := store.SetCheckpoint("order", tt.checkpoint); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := NewClient(WithBaseURL(ts.URL), WithPollInterval(time.Millisecond))
+			r := NewReactionRunner(c, store, tt.opts...)
+			if err := r.Register(&ReactionDefinition{Name: "payment-reminder", Feed: "order", ReactOnEventType: "OrderPlaced"}, func(context.Context, *Trigger) error { return nil }); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go r.Run(ctx, nil)
+
+			select {
+			case since := <-sincec:
+				if since != tt.wantSince {
+					t.Errorf("unexpected since = %q; want = %q", since, tt.wantSince)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("feed was never read")
+			}
+
+			seq, err := store.Checkpoint("order")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seq != tt.wantCheckpoint {
+				t.Errorf("unexpected checkpoint = %d; want = %d", seq, tt.wantCheckpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package serialized
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ReactionStatus represents the delivery status of a reaction.
+type ReactionStatus string
+
+// Valid reaction statuses.
+const (
+	ReactionStatusScheduled ReactionStatus = "SCHEDULED"
+	ReactionStatusReady     ReactionStatus = "READY"
+	ReactionStatusOngoing   ReactionStatus = "ONGOING"
+	ReactionStatusCompleted ReactionStatus = "COMPLETED"
+	ReactionStatusFailed    ReactionStatus = "FAILED"
+)
+
+// Reaction represents a scheduled or triggered reaction.
+type Reaction struct {
+	ID          string         `json:"reactionId"`
+	Name        string         `json:"reactionName"`
+	AggregateID string         `json:"aggregateId"`
+	EventID     string         `json:"eventId"`
+	Status      ReactionStatus `json:"status"`
+	CreatedAt   int64          `json:"createdAt"`
+	TriggerAt   int64          `json:"triggerAt"`
+	FinishedAt  int64          `json:"finishedAt,omitempty"`
+}
+
+// TriggerTime returns the time the reaction is, or was, set to trigger.
+func (r *Reaction) TriggerTime() time.Time {
+	return time.Unix(0, r.TriggerAt*int64(time.Millisecond))
+}
+
+// ReactionPage holds a page of reactions.
+type ReactionPage struct {
+	Reactions  []*Reaction `json:"reactions"`
+	HasMore    bool        `json:"hasMore"`
+	TotalCount int         `json:"totalCount"`
+}
+
+// ScheduledReactionsPage returns a page of reactions that are scheduled but
+// not yet triggered. Use WithReactionName, WithAggregateID and
+// WithTimeWindow to filter the reactions.
+func (c *Client) ScheduledReactionsPage(ctx context.Context, opts ...ReactionListOption) (*ReactionPage, error) {
+	return c.reactionsPage(ctx, "/reactions/scheduled", newReactionListOptions(opts...))
+}
+
+// TriggeredReactionsPage returns a page of reactions that have been
+// triggered, along with their delivery status. Use WithReactionName,
+// WithAggregateID and WithTimeWindow to filter the reactions.
+func (c *Client) TriggeredReactionsPage(ctx context.Context, opts ...ReactionListOption) (*ReactionPage, error) {
+	return c.reactionsPage(ctx, "/reactions/triggered", newReactionListOptions(opts...))
+}
+
+func (c *Client) reactionsPage(ctx context.Context, path string, o ReactionListOptions) (*ReactionPage, error) {
+	u := &url.URL{
+		Path:     path,
+		RawQuery: o.values().Encode(),
+	}
+
+	req, err := c.newRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(ReactionPage)
+	resp, err := c.do(ctx, req, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return page, nil
+}
+
+// CancelScheduledReaction cancels a reaction that has not yet been
+// triggered.
+func (c *Client) CancelScheduledReaction(ctx context.Context, id string) error {
+	req, err := c.newRequest("DELETE", "/reactions/scheduled/"+id, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ReexecuteReaction executes the action of a triggered reaction again, e.g.
+// after a failed delivery.
+func (c *Client) ReexecuteReaction(ctx context.Context, id string) error {
+	req, err := c.newRequest("POST", "/reactions/triggered/"+id, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package serialized
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduledReactionsPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/reactions/scheduled" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		want := url.Values{
+			"reactionName": []string{"payment-reminder"},
+			"from":         []string{"1514808000000"},
+			"limit":        []string{"10"},
+		}
+		if got := r.URL.Query(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected query = %v; want = %v", got, want)
+		}
+
+		b, err := loadJSON("testdata/reaction_scheduled_list_response.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	from := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	page, err := c.ScheduledReactionsPage(context.Background(),
+		WithReactionName("payment-reminder"),
+		WithTimeWindow(from, time.Time{}),
+		WithLimit(10),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Reactions) != 1 {
+		t.Fatalf("unexpected number of reactions = %d; want = %d", len(page.Reactions), 1)
+	}
+
+	want := &Reaction{
+		ID:          "7f1b1a8e-1ac4-4cbb-9c4b-c3f6a1f3c0a2",
+		Name:        "payment-reminder",
+		AggregateID: "2c3cf88c-ee88-427e-818a-ab0267511c84",
+		EventID:     "f2c8bfc1-c702-4f1a-b295-ef113ed7c8be",
+		Status:      ReactionStatusScheduled,
+		CreatedAt:   1514808000000,
+		TriggerAt:   1514811600000,
+	}
+	if !reflect.DeepEqual(page.Reactions[0], want) {
+		t.Fatalf("got = %v; want = %v", page.Reactions[0], want)
+	}
+	if !page.HasMore {
+		t.Errorf("page should have more reactions")
+	}
+	if got := want.TriggerTime(); !got.Equal(from.Add(time.Hour)) {
+		t.Errorf("unexpected trigger time = %s; want = %s", got, from.Add(time.Hour))
+	}
+}
+
+func TestCancelScheduledReaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Fatalf("unexpected method: %s", r.Method)
+		}
+		if r.URL.Path != "/reactions/scheduled/foo" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	if err := c.CancelScheduledReaction(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReexecuteReaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Fatalf("unexpected method: %s", r.Method)
+		}
+		if r.URL.Path != "/reactions/triggered/foo" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	if err := c.ReexecuteReaction(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+}
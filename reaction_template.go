@@ -0,0 +1,311 @@
+package serialized
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RenderTemplate renders an action body template for a reaction triggered by
+// the event in the given payload.
+//
+// Placeholders are written as ${name}, where name is one of reactionName,
+// aggregateId, eventId, eventType, or event.path.to.field to refer to a field
+// in the event data. Objects and arrays are rendered as JSON. Use $$ for a
+// literal $.
+func RenderTemplate(tmpl string, p *ReactionPayload) (string, error) {
+	return renderTemplate(tmpl, p, false)
+}
+
+// RenderJSONTemplate renders a JSON body template like RenderTemplate, but
+// escapes the substituted values by where they're used. Within a JSON
+// string, e.g. {"name":"${event.name}"}, values are escaped as string
+// content. Elsewhere, e.g. {"name":${event.name}}, values are rendered as
+// JSON, so that strings are quoted.
+func RenderJSONTemplate(tmpl string, p *ReactionPayload) (string, error) {
+	return renderTemplate(tmpl, p, true)
+}
+
+func renderTemplate(tmpl string, p *ReactionPayload, escape bool) (string, error) {
+	var data interface{}
+	if p.Event != nil && len(p.Event.Data) > 0 {
+		if err := json.Unmarshal(p.Event.Data, &data); err != nil {
+			return "", fmt.Errorf("unable to decode event data: %s", err)
+		}
+	}
+
+	var (
+		b        strings.Builder
+		inString bool
+	)
+
+	// literal writes a part of the template, and keeps track of whether
+	// the next placeholder is within a JSON string.
+	literal := func(s string) {
+		b.WriteString(s)
+		inString = inJSONString(s, inString)
+	}
+
+	for {
+		i := strings.IndexByte(tmpl, '$')
+		if i < 0 || i == len(tmpl)-1 {
+			literal(tmpl)
+			break
+		}
+
+		literal(tmpl[:i])
+		tmpl = tmpl[i:]
+
+		switch tmpl[1] {
+		case '$':
+			literal("$")
+			tmpl = tmpl[2:]
+			continue
+		case '{':
+		default:
+			literal("$")
+			tmpl = tmpl[1:]
+			continue
+		}
+
+		end := strings.IndexByte(tmpl, '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder %q", tmpl)
+		}
+
+		name := strings.TrimSpace(tmpl[2:end])
+		tmpl = tmpl[end+1:]
+
+		v, err := placeholderValue(name, p, data)
+		if err != nil {
+			return "", err
+		}
+
+		var s string
+		switch {
+		case !escape:
+			s, err = formatText(v)
+		case inString:
+			if s, err = formatText(v); err == nil {
+				s, err = escapeJSONString(s)
+			}
+		default:
+			s, err = formatJSON(v)
+		}
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+	}
+
+	return b.String(), nil
+}
+
+// inJSONString reports whether the end of s is within a JSON string, given
+// whether its start is.
+func inJSONString(s string, in bool) bool {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case in && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			in = !in
+		}
+	}
+	return in
+}
+
+// placeholderValue returns the value of a placeholder, either a string or a
+// value decoded from the event data.
+func placeholderValue(name string, p *ReactionPayload, data interface{}) (interface{}, error) {
+	var ev Event
+	if p.Event != nil {
+		ev = *p.Event
+	}
+
+	switch name {
+	case "reactionName":
+		return p.ReactionName, nil
+	case "aggregateId":
+		return p.AggregateID, nil
+	case "eventId":
+		return ev.ID, nil
+	case "eventType":
+		return ev.Type, nil
+	}
+
+	if !strings.HasPrefix(name, "event.") {
+		return nil, fmt.Errorf("unknown placeholder ${%s}", name)
+	}
+
+	v := data
+	for _, key := range strings.Split(strings.TrimPrefix(name, "event."), ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("placeholder ${%s} not found in event data", name)
+		}
+		if v, ok = m[key]; !ok {
+			return nil, fmt.Errorf("placeholder ${%s} not found in event data", name)
+		}
+	}
+
+	return v, nil
+}
+
+// formatText formats a value as text. Strings are used as they are, and
+// objects and arrays are rendered as JSON.
+func formatText(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return formatJSON(v)
+}
+
+// formatJSON formats a value as JSON.
+func formatJSON(v interface{}) (string, error) {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(buf.Bytes())), nil
+}
+
+// escapeJSONString returns s escaped for use within a JSON string, without
+// the surrounding quotes.
+func escapeJSONString(s string) (string, error) {
+	b, err := formatJSON(s)
+	if err != nil {
+		return "", err
+	}
+	return b[1 : len(b)-1], nil
+}
+
+// PreviewReaction returns the request that would be sent when the reaction
+// is triggered by the given event. EMAIL actions are delivered by
+// Serialized.io rather than by a request, and can't be previewed.
+//
+// An Event doesn't hold the ID of its aggregate, which is needed for the
+// aggregateId of the payload and the ${aggregateId} placeholder, so it's
+// given as well.
+func PreviewReaction(def *ReactionDefinition, aggID string, ev *Event) (*http.Request, error) {
+	a := def.Action
+	if a == nil {
+		return nil, fmt.Errorf("reaction %q has no action", def.Name)
+	}
+
+	p := &ReactionPayload{
+		ReactionName: def.Name,
+		AggregateID:  aggID,
+		Event:        ev,
+	}
+
+	cfg := a.Config
+	if cfg == nil {
+		cfg = newActionConfig(a.ActionType)
+	}
+
+	var (
+		body   interface{}
+		header = make(http.Header)
+		secret string
+	)
+
+	switch cfg := cfg.(type) {
+	case *HTTPConfig:
+		for k, v := range cfg.Headers {
+			header.Set(k, v)
+		}
+		secret = cfg.SigningSecret
+
+		if a.Body == "" {
+			body = p
+			break
+		}
+
+		s, err := RenderJSONTemplate(a.Body, p)
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(s)
+	case *SlackConfig:
+		s, err := RenderTemplate(a.Body, p)
+		if err != nil {
+			return nil, err
+		}
+
+		body = struct {
+			Text    string            `json:"text,omitempty"`
+			Channel string            `json:"channel,omitempty"`
+			Blocks  []json.RawMessage `json:"blocks,omitempty"`
+		}{s, cfg.Channel, cfg.Blocks}
+	case *IFTTTConfig:
+		values := make(map[string]string)
+		for k, tmpl := range map[string]string{"value1": cfg.Value1, "value2": cfg.Value2, "value3": cfg.Value3} {
+			if tmpl == "" {
+				continue
+			}
+			s, err := RenderTemplate(tmpl, p)
+			if err != nil {
+				return nil, err
+			}
+			values[k] = s
+		}
+		body = values
+	case *ZapierConfig:
+		if len(cfg.Fields) == 0 {
+			body = p
+			break
+		}
+
+		fields := make(map[string]string)
+		for k, tmpl := range cfg.Fields {
+			s, err := RenderTemplate(tmpl, p)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = s
+		}
+		body = fields
+	case *EmailConfig:
+		return nil, fmt.Errorf("%s actions can't be previewed as requests", a.ActionType)
+	default:
+		return nil, fmt.Errorf("unsupported action type %q", a.ActionType)
+	}
+
+	b, ok := body.([]byte)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest("POST", a.TargetURI, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(secret), b))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,181 @@
+package serialized
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	p := &ReactionPayload{
+		ReactionName: "payment-reaction",
+		AggregateID:  "a",
+		Event: &Event{
+			ID:   "1",
+			Type: "PaymentProcessed",
+			Data: json.RawMessage(`{"amount":1000,"currency":"SEK","card":{"last4":"4242"},"tags":["a"]}`),
+		},
+	}
+
+	for _, tt := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "no placeholders", want: "no placeholders"},
+		{in: "${reactionName}: ${eventType} ${eventId} for ${aggregateId}", want: "payment-reaction: PaymentProcessed 1 for a"},
+		{in: "Paid ${event.amount} ${ event.currency } with ${event.card.last4}", want: "Paid 1000 SEK with 4242"},
+		{in: `{"card":${event.card},"tags":${event.tags}}`, want: `{"card":{"last4":"4242"},"tags":["a"]}`},
+		{in: "costs $$5 or $5", want: "costs $5 or $5"},
+		{in: "${event.missing}", wantErr: true},
+		{in: "${unknown}", wantErr: true},
+		{in: "${event.amount", wantErr: true},
+	} {
+		got, err := RenderTemplate(tt.in, p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RenderTemplate(%q): err = %v; wantErr = %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderTemplate(%q) = %q; want = %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderJSONTemplate(t *testing.T) {
+	p := &ReactionPayload{
+		ReactionName: "payment-reaction",
+		AggregateID:  "a",
+		Event: &Event{
+			ID:   "1",
+			Type: "PaymentProcessed",
+			Data: json.RawMessage(`{"name":"Bob \"The Builder\" \\ <b>","amount":1000,"card":{"last4":"4242"}}`),
+		},
+	}
+
+	got, err := RenderJSONTemplate(`{"name":"${event.name}","amount":${event.amount},"card":${event.card}}`, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct {
+		Name   string `json:"name"`
+		Amount int    `json:"amount"`
+		Card   struct {
+			Last4 string `json:"last4"`
+		} `json:"card"`
+	}
+	if err := json.Unmarshal([]byte(got), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %s", got, err)
+	}
+
+	if want := `Bob "The Builder" \ <b>`; v.Name != want {
+		t.Errorf("got = %q; want = %q", v.Name, want)
+	}
+	if v.Amount != 1000 || v.Card.Last4 != "4242" {
+		t.Errorf("got = %s", got)
+	}
+}
+
+func TestRenderJSONTemplateEscaping(t *testing.T) {
+	p := &ReactionPayload{
+		ReactionName: "payment-reaction",
+		AggregateID:  "a",
+		Event: &Event{
+			ID:   "1",
+			Type: "PaymentProcessed",
+			Data: json.RawMessage(`{"name":"Bob \"B\"","amount":1000,"card":{"last4":"4242"},"note":null}`),
+		},
+	}
+
+	for _, tt := range []struct {
+		in   string
+		want string
+	}{
+		{in: `{"name":${event.name}}`, want: `{"name":"Bob \"B\""}`},
+		{in: `{"name":"${event.name}"}`, want: `{"name":"Bob \"B\""}`},
+		{in: `{"id":${aggregateId}}`, want: `{"id":"a"}`},
+		{in: `{"amount":${event.amount},"text":"${event.amount} SEK"}`, want: `{"amount":1000,"text":"1000 SEK"}`},
+		{in: `{"card":${event.card},"text":"${event.card}"}`, want: `{"card":{"last4":"4242"},"text":"{\"last4\":\"4242\"}"}`},
+		{in: `{"note":${event.note},"text":"${event.note}"}`, want: `{"note":null,"text":"null"}`},
+		{in: `{"a\"${eventId}":"\\","b":${eventId}}`, want: `{"a\"1":"\\","b":"1"}`},
+	} {
+		got, err := RenderJSONTemplate(tt.in, p)
+		if err != nil {
+			t.Errorf("RenderJSONTemplate(%s) returned error: %s", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderJSONTemplate(%s) = %s; want = %s", tt.in, got, tt.want)
+		}
+		if !json.Valid([]byte(got)) {
+			t.Errorf("RenderJSONTemplate(%s) = %s; not valid JSON", tt.in, got)
+		}
+	}
+}
+
+func TestPreviewReaction(t *testing.T) {
+	def := &ReactionDefinition{
+		Name: "payment-reaction",
+		Action: &Action{
+			ActionType: ActionTypeSlackPost,
+			TargetURI:  "https://hooks.slack.com/services/T0/B0/X",
+			Body:       "Received ${event.amount} ${event.currency}",
+		},
+	}
+
+	ev := &Event{
+		ID:   "1",
+		Type: "PaymentProcessed",
+		Data: json.RawMessage(`{"amount":1000,"currency":"SEK"}`),
+	}
+
+	req, err := PreviewReaction(def, "a", ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "POST" {
+		t.Errorf("unexpected method = %s; want = %s", req.Method, "POST")
+	}
+	if req.URL.String() != def.Action.TargetURI {
+		t.Errorf("unexpected url = %s; want = %s", req.URL, def.Action.TargetURI)
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualJSON(t, b, []byte(`{"text":"Received 1000 SEK"}`))
+}
+
+func TestPreviewReactionSigned(t *testing.T) {
+	def := &ReactionDefinition{
+		Name: "payment-reaction",
+		Action: &Action{
+			ActionType: ActionTypeHTTPPost,
+			TargetURI:  "https://example.com/hooks",
+			Config: &HTTPConfig{
+				Headers:       map[string]string{"X-Tenant": "acme"},
+				SigningSecret: "s3cr3t",
+			},
+		},
+	}
+
+	req, err := PreviewReaction(def, "a", &Event{ID: "1", Type: "PaymentProcessed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("X-Tenant"); got != "acme" {
+		t.Errorf("unexpected header = %s; want = %s", got, "acme")
+	}
+	if got, want := req.Header.Get(SignatureHeader), Sign([]byte("s3cr3t"), b); got != want {
+		t.Errorf("unexpected signature = %s; want = %s", got, want)
+	}
+}
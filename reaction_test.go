@@ -34,6 +34,27 @@ func TestListReactions(t *testing.T) {
 	}
 }
 
+func TestReactionDefinitionsCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.ListReactionDefinitions(ctx); err != context.Canceled {
+		t.Errorf("unexpected error = %v; want = %v", err, context.Canceled)
+	}
+	if err := c.CreateReactionDefinition(ctx, &ReactionDefinition{Name: "payment-processed"}); err != context.Canceled {
+		t.Errorf("unexpected error = %v; want = %v", err, context.Canceled)
+	}
+}
+
 func TestCreateReaction(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		got, err := ioutil.ReadAll(r.Body)
@@ -70,6 +91,47 @@ func TestCreateReaction(t *testing.T) {
 	}
 }
 
+func TestUpdateReaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Fatalf("unexpected method = %s; want = %s", r.Method, "PUT")
+		}
+		if r.URL.Path != "/reactions/definitions/payment-processed-email-reaction" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		got, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := loadJSON("testdata/reaction_create_request.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqualJSON(t, got, want)
+	}))
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+	)
+
+	r := &ReactionDefinition{
+		Name:               "payment-processed-email-reaction",
+		Feed:               "payment",
+		ReactOnEventType:   "PaymentProcessed",
+		CancelOnEventTypes: []string{"OrderCanceledEvent"},
+		TriggerTimeField:   "my.event.data.field",
+		Offset:             "PT1H",
+		Action: &Action{
+			ActionType: ActionTypeHTTPPost,
+		},
+	}
+
+	if err := c.UpdateReactionDefinition(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDeleteReaction(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	}))
@@ -119,3 +181,46 @@ func TestGetReaction(t *testing.T) {
 		t.Fatalf("got = %v; want = %v", got, want)
 	}
 }
+
+func TestReactionDefinitionValidate(t *testing.T) {
+	valid := func() *ReactionDefinition {
+		return &ReactionDefinition{
+			Name:               "payment-processed-email-reaction",
+			Feed:               "payment",
+			ReactOnEventType:   "PaymentProcessed",
+			CancelOnEventTypes: []string{"OrderCanceledEvent"},
+			TriggerTimeField:   "my.event.data.field",
+			Offset:             "PT1H",
+			Action: &Action{
+				ActionType: ActionTypeHTTPPost,
+				TargetURI:  "https://example.com/hooks",
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		modify  func(*ReactionDefinition)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(r *ReactionDefinition) {}},
+		{name: "missing name", modify: func(r *ReactionDefinition) { r.Name = "" }, wantErr: true},
+		{name: "invalid offset", modify: func(r *ReactionDefinition) { r.Offset = "1H" }, wantErr: true},
+		{name: "invalid trigger time field", modify: func(r *ReactionDefinition) { r.TriggerTimeField = "my..field" }, wantErr: true},
+		{name: "overlapping event types", modify: func(r *ReactionDefinition) { r.CancelOnEventTypes = []string{"PaymentProcessed"} }, wantErr: true},
+		{name: "missing action", modify: func(r *ReactionDefinition) { r.Action = nil }, wantErr: true},
+		{name: "missing target", modify: func(r *ReactionDefinition) { r.Action.TargetURI = "" }, wantErr: true},
+		{name: "relative target", modify: func(r *ReactionDefinition) { r.Action.TargetURI = "/hooks" }, wantErr: true},
+		{name: "unknown action type", modify: func(r *ReactionDefinition) { r.Action.ActionType = "FAX" }, wantErr: true},
+		{name: "slack without body", modify: func(r *ReactionDefinition) { r.Action.ActionType = ActionTypeSlackPost }, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(r)
+
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package serialized
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trigger is a reaction scheduled by a ReactionRunner.
+type Trigger struct {
+	ID           string    `json:"id"`
+	ReactionName string    `json:"reactionName"`
+	Feed         string    `json:"feedName"`
+	AggregateID  string    `json:"aggregateId"`
+	Event        *Event    `json:"event"`
+	TriggerAt    time.Time `json:"triggerAt"`
+
+	// SequenceNumber is the sequence number of the feed entry that
+	// scheduled the trigger.
+	SequenceNumber int64 `json:"sequenceNumber"`
+}
+
+// TriggerStore persists the state of a ReactionRunner.
+type TriggerStore interface {
+	// Schedule stores a pending trigger. Triggers with an ID that has
+	// already been scheduled, or completed, are ignored.
+	Schedule(t *Trigger) error
+
+	// Complete removes a pending trigger, and makes sure it's never
+	// scheduled again. It reports whether the trigger was still pending, so
+	// that only one of several concurrent callers claims it. Once the
+	// checkpoint of its feed has passed the entry that scheduled it, the
+	// store may forget the trigger, since the entry is never processed
+	// again.
+	Complete(id string) (bool, error)
+
+	// Pending returns all pending triggers, ordered by trigger time.
+	Pending() ([]*Trigger, error)
+
+	// Checkpoint returns the sequence number of the last processed entry
+	// of a feed.
+	Checkpoint(feed string) (int64, error)
+
+	// SetCheckpoint sets the sequence number of the last processed entry
+	// of a feed.
+	SetCheckpoint(feed string, seq int64) error
+}
+
+// triggerState is the state held by the built-in trigger stores.
+type triggerState struct {
+	Pending     map[string]*Trigger          `json:"pending"`
+	Completed   map[string]*completedTrigger `json:"completed"`
+	Checkpoints map[string]int64             `json:"checkpoints"`
+}
+
+// completedTrigger is kept until the checkpoint of its feed has passed the
+// entry that scheduled it.
+type completedTrigger struct {
+	Feed           string `json:"feedName,omitempty"`
+	SequenceNumber int64  `json:"sequenceNumber,omitempty"`
+}
+
+func newTriggerState() *triggerState {
+	return &triggerState{
+		Pending:     make(map[string]*Trigger),
+		Completed:   make(map[string]*completedTrigger),
+		Checkpoints: make(map[string]int64),
+	}
+}
+
+func (s *triggerState) schedule(t *Trigger) bool {
+	if _, ok := s.Pending[t.ID]; ok || s.Completed[t.ID] != nil {
+		return false
+	}
+	s.Pending[t.ID] = t
+	return true
+}
+
+// complete marks a trigger as completed, and reports whether it was
+// pending.
+func (s *triggerState) complete(id string) bool {
+	c := &completedTrigger{}
+	t, ok := s.Pending[id]
+	if ok {
+		c.Feed, c.SequenceNumber = t.Feed, t.SequenceNumber
+		delete(s.Pending, id)
+	}
+
+	if !s.processed(c) && s.Completed[id] == nil {
+		s.Completed[id] = c
+	}
+
+	return ok
+}
+
+// setCheckpoint sets the checkpoint of a feed, and forgets the completed
+// triggers that were scheduled by entries up to it.
+func (s *triggerState) setCheckpoint(feed string, seq int64) {
+	s.Checkpoints[feed] = seq
+
+	for id, c := range s.Completed {
+		if c.Feed == feed && s.processed(c) {
+			delete(s.Completed, id)
+		}
+	}
+}
+
+// processed reports whether the entry that scheduled a completed trigger
+// is at or before the checkpoint of its feed. Triggers without a sequence
+// number are never considered processed.
+func (s *triggerState) processed(c *completedTrigger) bool {
+	return c.SequenceNumber > 0 && c.SequenceNumber <= s.Checkpoints[c.Feed]
+}
+
+func (s *triggerState) pending() []*Trigger {
+	res := make([]*Trigger, 0, len(s.Pending))
+	for _, t := range s.Pending {
+		res = append(res, t)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].TriggerAt.Equal(res[j].TriggerAt) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].TriggerAt.Before(res[j].TriggerAt)
+	})
+
+	return res
+}
+
+// MemoryTriggerStore is a TriggerStore that keeps its state in memory. It
+// doesn't survive restarts.
+type MemoryTriggerStore struct {
+	mu    sync.Mutex
+	state *triggerState
+}
+
+// NewMemoryTriggerStore returns a new MemoryTriggerStore.
+func NewMemoryTriggerStore() *MemoryTriggerStore {
+	return &MemoryTriggerStore{state: newTriggerState()}
+}
+
+// Schedule stores a pending trigger.
+func (s *MemoryTriggerStore) Schedule(t *Trigger) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.schedule(t)
+	return nil
+}
+
+// Complete removes a pending trigger, and reports whether it was pending.
+func (s *MemoryTriggerStore) Complete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.complete(id), nil
+}
+
+// Pending returns all pending triggers.
+func (s *MemoryTriggerStore) Pending() ([]*Trigger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.pending(), nil
+}
+
+// Checkpoint returns the sequence number of the last processed entry.
+func (s *MemoryTriggerStore) Checkpoint(feed string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Checkpoints[feed], nil
+}
+
+// SetCheckpoint sets the sequence number of the last processed entry.
+func (s *MemoryTriggerStore) SetCheckpoint(feed string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.setCheckpoint(feed, seq)
+	return nil
+}
+
+// fileTriggerStoreSaveInterval is how often a FileTriggerStore writes
+// scheduled triggers and checkpoints to its file.
+const fileTriggerStoreSaveInterval = time.Second
+
+// FileTriggerStore is a TriggerStore that keeps its state in a JSON file.
+//
+// Completed triggers are written to the file before Complete returns, so
+// that a reaction never fires twice. Scheduled triggers and checkpoints are
+// written at most once a second, and by Flush. If the process stops before
+// they're written, the entries since the last written checkpoint are
+// processed again, which schedules the same triggers.
+type FileTriggerStore struct {
+	path string
+
+	mu       sync.Mutex
+	state    *triggerState
+	dirty    bool
+	lastSave time.Time
+}
+
+// NewFileTriggerStore returns a FileTriggerStore for the file at path. If the
+// file exists, the state is loaded from it.
+func NewFileTriggerStore(path string) (*FileTriggerStore, error) {
+	s := &FileTriggerStore{
+		path:  path,
+		state: newTriggerState(),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, s.state); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Schedule stores a pending trigger.
+func (s *FileTriggerStore) Schedule(t *Trigger) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.schedule(t) {
+		return nil
+	}
+	return s.saveLater()
+}
+
+// Complete removes a pending trigger, and reports whether it was pending.
+func (s *FileTriggerStore) Complete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ok := s.state.complete(id)
+	if err := s.save(); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// Pending returns all pending triggers.
+func (s *FileTriggerStore) Pending() ([]*Trigger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.pending(), nil
+}
+
+// Checkpoint returns the sequence number of the last processed entry.
+func (s *FileTriggerStore) Checkpoint(feed string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Checkpoints[feed], nil
+}
+
+// SetCheckpoint sets the sequence number of the last processed entry.
+func (s *FileTriggerStore) SetCheckpoint(feed string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.setCheckpoint(feed, seq)
+	return s.saveLater()
+}
+
+// Flush writes changes that haven't been written yet to the file.
+func (s *FileTriggerStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
+
+// saveLater writes the state to the file, unless it was written less than
+// fileTriggerStoreSaveInterval ago.
+func (s *FileTriggerStore) saveLater() error {
+	s.dirty = true
+
+	if time.Since(s.lastSave) < fileTriggerStoreSaveInterval {
+		return nil
+	}
+	return s.save()
+}
+
+// save writes the state to the file.
+func (s *FileTriggerStore) save() error {
+	if err := writeJSONFile(s.path, s.state); err != nil {
+		return err
+	}
+
+	s.dirty = false
+	s.lastSave = time.Now()
+
+	return nil
+}
+
+// writeJSONFile writes v as JSON to a temporary file and renames it, so that
+// the file is never left half-written.
+func writeJSONFile(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
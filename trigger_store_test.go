@@ -0,0 +1,193 @@
+package serialized
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTriggerStores(t *testing.T) {
+	file, err := NewFileTriggerStore(filepath.Join(t.TempDir(), "triggers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]TriggerStore{
+		"memory": NewMemoryTriggerStore(),
+		"file":   file,
+	} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			for _, tr := range []*Trigger{
+				{ID: "r/2", TriggerAt: now.Add(time.Hour)},
+				{ID: "r/1", TriggerAt: now},
+				{ID: "r/1", TriggerAt: now.Add(2 * time.Hour)},
+			} {
+				if err := store.Schedule(tr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pending, err := store.Pending()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != 2 || pending[0].ID != "r/1" || pending[1].ID != "r/2" {
+				t.Fatalf("unexpected pending triggers = %v", pending)
+			}
+
+			if ok, err := store.Complete("r/1"); err != nil || !ok {
+				t.Fatalf("got = %v, %v; want = %v", ok, err, true)
+			}
+			if ok, err := store.Complete("r/1"); err != nil || ok {
+				t.Fatalf("got = %v, %v; want = %v", ok, err, false)
+			}
+			if err := store.Schedule(&Trigger{ID: "r/1", TriggerAt: now}); err != nil {
+				t.Fatal(err)
+			}
+
+			pending, err = store.Pending()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != 1 || pending[0].ID != "r/2" {
+				t.Fatalf("unexpected pending triggers = %v", pending)
+			}
+
+			if err := store.SetCheckpoint("order", 42); err != nil {
+				t.Fatal(err)
+			}
+			if seq, err := store.Checkpoint("order"); err != nil || seq != 42 {
+				t.Fatalf("got = %d, %v; want = %d", seq, err, 42)
+			}
+		})
+	}
+}
+
+func TestFileTriggerStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+
+	s, err := NewFileTriggerStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule(&Trigger{ID: "r/1", Event: &Event{ID: "1", Type: "OrderPlaced"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule(&Trigger{ID: "r/2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete("r/2"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewFileTriggerStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := s.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Event.Type != "OrderPlaced" {
+		t.Fatalf("unexpected pending triggers = %v", pending)
+	}
+
+	if err := s.Schedule(&Trigger{ID: "r/2"}); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := s.Pending(); len(pending) != 1 {
+		t.Fatalf("completed trigger was scheduled again")
+	}
+}
+
+func TestTriggerStatePrunesCompleted(t *testing.T) {
+	s := newTriggerState()
+
+	s.schedule(&Trigger{ID: "r/1", Feed: "order", SequenceNumber: 1})
+	s.schedule(&Trigger{ID: "r/2", Feed: "order", SequenceNumber: 2})
+	s.schedule(&Trigger{ID: "r/3", Feed: "payment", SequenceNumber: 1})
+	s.complete("r/1")
+	s.complete("r/2")
+	s.complete("r/3")
+	s.complete("unknown")
+
+	s.setCheckpoint("order", 1)
+
+	if len(s.Completed) != 3 || s.Completed["r/1"] != nil {
+		t.Fatalf("unexpected completed triggers = %s", mustMarshal(s.Completed))
+	}
+	if s.schedule(&Trigger{ID: "r/2", Feed: "order", SequenceNumber: 2}) {
+		t.Fatalf("completed trigger was scheduled again")
+	}
+
+	// Triggers completed after the checkpoint passed them aren't kept.
+	s.schedule(&Trigger{ID: "r/0", Feed: "order", SequenceNumber: 1})
+	s.complete("r/0")
+
+	s.setCheckpoint("order", 2)
+
+	if len(s.Completed) != 2 || s.Completed["r/3"] == nil || s.Completed["unknown"] == nil {
+		t.Fatalf("unexpected completed triggers = %s", mustMarshal(s.Completed))
+	}
+}
+
+func TestFileTriggerStoreFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+
+	s, err := NewFileTriggerStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := func() int64 {
+		s, err := NewFileTriggerStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq, err := s.Checkpoint("order")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seq
+	}
+
+	for seq := int64(1); seq <= 3; seq++ {
+		if err := s.SetCheckpoint("order", seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the first change is written until the state is flushed.
+	if seq := checkpoint(); seq != 1 {
+		t.Fatalf("got checkpoint = %d; want = %d", seq, 1)
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if seq := checkpoint(); seq != 3 {
+		t.Fatalf("got checkpoint = %d; want = %d", seq, 3)
+	}
+
+	// Completed triggers are written right away.
+	if err := s.Schedule(&Trigger{ID: "r/1", Feed: "order", SequenceNumber: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete("r/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewFileTriggerStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Schedule(&Trigger{ID: "r/1", Feed: "order", SequenceNumber: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := reloaded.Pending(); len(pending) != 0 {
+		t.Fatalf("completed trigger was scheduled again")
+	}
+}